@@ -0,0 +1,51 @@
+package counters
+
+import "sync/atomic"
+
+// Accumulator sums signed deltas, its value may go negative, e.g. a net flow
+// of bytes received minus bytes sent. Unlike Counter it's meant to be
+// updated with deltas of both signs, accumulators are printed by WriteTo in
+// a separate section.
+type Accumulator interface {
+	// Add adds delta to the value and returns the new value.
+	Add(delta int64) int64
+	// Name returns a name of accumulator.
+	Name() string
+	// Value returns a current value.
+	Value() int64
+}
+
+type accumulatorImpl struct {
+	name  string
+	value int64
+}
+
+func (a *accumulatorImpl) Add(delta int64) int64 {
+	return atomic.AddInt64(&a.value, delta)
+}
+
+func (a *accumulatorImpl) Name() string {
+	return a.name
+}
+
+func (a *accumulatorImpl) Value() int64 {
+	return atomic.LoadInt64(&a.value)
+}
+
+// GetAccumulator returns an accumulator of given name, if doesn't exist than
+// create. Accumulators are kept apart from counters, so they may share names.
+func (c *CounterBox) GetAccumulator(name string) Accumulator {
+	name = c.checkName(name)
+	value, ok := c.accumulators.Load(name)
+	if !ok {
+		value, _ = c.accumulators.LoadOrStore(name, &accumulatorImpl{name: name})
+	}
+	v, _ := value.(Accumulator)
+	return v
+}
+
+// AccumulatorPairs returns names and values of all accumulators sorted
+// by name.
+func (c *CounterBox) AccumulatorPairs() []CounterPair {
+	return sortedPairs(&c.accumulators)
+}
@@ -0,0 +1,42 @@
+package counters
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAccumulator(t *testing.T) {
+	box := NewCounterBox()
+	flow := box.GetAccumulator("flow")
+	if v := flow.Add(100); v != 100 {
+		t.Errorf("want: 100, got %d", v)
+	}
+	if v := flow.Add(-250); v != -150 {
+		t.Errorf("want: -150, got %d", v)
+	}
+	flow.Add(30)
+	if v := box.GetAccumulator("flow").Value(); v != -120 {
+		t.Errorf("want: -120, got %d", v)
+	}
+	if _, ok := box.PeekCounter("flow"); ok {
+		t.Error("want accumulator kept apart from counters")
+	}
+}
+
+func TestAccumulatorWriteTo(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("flow").Increment()
+	box.GetAccumulator("flow").Add(-7)
+	want := `== Counters ==
+  flow: 1
+== Min values ==
+== Max values ==
+== Accumulators ==
+  flow: -7`
+	if s := box.String(); s != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, s)
+	}
+	if s := NewCounterBox().String(); strings.Contains(s, "Accumulators") {
+		t.Errorf("want no accumulators section, got:\n%s", s)
+	}
+}
@@ -0,0 +1,84 @@
+package counters
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock which moves only when told to.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) NewTicker(d time.Duration) Ticker {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTicker{
+		period:  d,
+		next:    f.now.Add(d),
+		c:       make(chan time.Time),
+		stopped: make(chan struct{}),
+	}
+	f.tickers = append(f.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward by d and delivers ticks of all tickers
+// which are due. Every tick is sent synchronously, so Advance returns after
+// the ticks were received.
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	now := f.now
+	tickers := append([]*fakeTicker(nil), f.tickers...)
+	f.mu.Unlock()
+	for _, t := range tickers {
+		for !t.next.After(now) {
+			select {
+			case t.c <- t.next:
+			case <-t.stopped:
+			}
+			t.next = t.next.Add(t.period)
+		}
+	}
+}
+
+type fakeTicker struct {
+	period  time.Duration
+	next    time.Time
+	c       chan time.Time
+	once    sync.Once
+	stopped chan struct{}
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.once.Do(func() { close(t.stopped) })
+}
+
+// waitFor waits until cond is true or fails the test after a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,114 @@
+package counters
+
+import (
+	"sync"
+	"time"
+)
+
+// computedCounter is a read only counter which value is computed by
+// a function.
+type computedCounter struct {
+	name string
+	box  *CounterBox
+	fn   func(box *CounterBox) int64
+}
+
+func (c *computedCounter) Increment() int64 {
+	return c.Value()
+}
+
+func (c *computedCounter) IncrementBy(num int) int64 {
+	return c.Value()
+}
+
+func (c *computedCounter) Decrement() int64 {
+	return c.Value()
+}
+
+func (c *computedCounter) DecrementBy(num int) int64 {
+	return c.Value()
+}
+
+func (c *computedCounter) IncrementByDuration(d time.Duration) int64 {
+	return c.Value()
+}
+
+func (c *computedCounter) Set(num int) {}
+
+func (c *computedCounter) Kind() MetricKind {
+	return KindGauge
+}
+
+func (c *computedCounter) Name() string {
+	return c.name
+}
+
+// Value returns the result of the function, or 0 if it panics.
+func (c *computedCounter) Value() (v int64) {
+	defer func() {
+		if recover() != nil {
+			v = 0
+		}
+	}()
+	return c.fn(c.box)
+}
+
+// isComputed reports whether v is a computed counter. Its value runs a user
+// function which may read the box, so it must not be read under the box
+// lock.
+func isComputed(v interface{}) bool {
+	_, ok := v.(*computedCounter)
+	return ok
+}
+
+// collectLocked works like collectPairs but skips computed counters, it's
+// used under the box lock, see computedPairs.
+func collectLocked(m *sync.Map) []CounterPair {
+	var pairs []CounterPair
+	m.Range(func(key interface{}, value interface{}) bool {
+		if value, ok := value.(namedValue); ok && !isComputed(value) {
+			pairs = append(pairs, CounterPair{value.Name(), value.Value()})
+		}
+		return true
+	})
+	return pairs
+}
+
+// computedPairs returns values of all computed counters in the map order,
+// it must be called without the box lock held.
+func (c *CounterBox) computedPairs() []CounterPair {
+	var computed []*computedCounter
+	c.counters.Range(func(key interface{}, value interface{}) bool {
+		if v, ok := value.(*computedCounter); ok {
+			computed = append(computed, v)
+		}
+		return true
+	})
+	pairs := make([]CounterPair, 0, len(computed))
+	for _, v := range computed {
+		pairs = append(pairs, CounterPair{v.name, v.Value()})
+	}
+	return pairs
+}
+
+// RegisterComputed registers a read only counter of given name which value
+// is computed by fn every time it's read, e.g. a ratio of other counters.
+// It replaces a counter of the same name. Updates of the counter are ignored.
+// If fn panics the value is 0. Operations reading several counters under the
+// box lock, e.g. Snapshot or Values, call fn after the lock is released, so
+// fn may read the box, but its value isn't consistent with the other ones.
+func (c *CounterBox) RegisterComputed(name string, fn func(box *CounterBox) int64) {
+	name = c.checkName(name)
+	c.claimName(name, KindCounter)
+	c.counters.Store(name, &computedCounter{name, c, fn})
+}
+
+// TrackUptime registers a computed counter of given name which value is the
+// number of whole seconds since TrackUptime was called, measured with the
+// box clock.
+func (c *CounterBox) TrackUptime(name string) {
+	start := c.clock.Now()
+	c.RegisterComputed(name, func(box *CounterBox) int64 {
+		return int64(box.clock.Now().Sub(start) / time.Second)
+	})
+}
@@ -0,0 +1,91 @@
+package counters
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterComputed(t *testing.T) {
+	box := NewCounterBox()
+	box.RegisterComputed("error_rate", func(box *CounterBox) int64 {
+		return box.GetCounter("errors").Value() * 100 / box.GetCounter("total").Value()
+	})
+	rate := box.GetCounter("error_rate")
+
+	// Division by zero is recovered.
+	if v := rate.Value(); v != 0 {
+		t.Errorf("want: 0, got %d", v)
+	}
+	box.GetCounter("total").IncrementBy(10)
+	box.GetCounter("errors").IncrementBy(1)
+	if v := rate.Value(); v != 10 {
+		t.Errorf("want: 10, got %d", v)
+	}
+	box.GetCounter("errors").IncrementBy(4)
+	if v := rate.Value(); v != 50 {
+		t.Errorf("want: 50, got %d", v)
+	}
+	rate.IncrementBy(7)
+	rate.Set(1)
+	if v := rate.Value(); v != 50 {
+		t.Errorf("computed counter should not change, got %d", v)
+	}
+	if s := box.String(); !strings.Contains(s, "error_rate: 50") {
+		t.Errorf("output should contain the computed counter:\n%s", s)
+	}
+}
+
+func TestTrackUptime(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	box.TrackUptime("uptime")
+	if v := box.GetCounter("uptime").Value(); v != 0 {
+		t.Errorf("want: 0, got %d", v)
+	}
+	clock.Advance(90*time.Second + 500*time.Millisecond)
+	if v := box.GetCounter("uptime").Value(); v != 90 {
+		t.Errorf("want: 90, got %d", v)
+	}
+	if s := box.String(); !strings.Contains(s, "uptime: 90") {
+		t.Errorf("want uptime in the output, got %q", s)
+	}
+}
+
+func TestComputedReadsBox(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("errors").IncrementBy(3)
+	box.RegisterComputed("errors.copy", func(b *CounterBox) int64 {
+		return b.Values("errors")["errors"]
+	})
+	// GrandTotal skips computed counters, so it's the value of errors.
+	box.RegisterComputed("total", func(b *CounterBox) int64 {
+		return b.GrandTotal()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if v := box.Snapshot().Counters["errors.copy"]; v != 3 {
+			t.Errorf("snapshot, want: 3, got %d", v)
+		}
+		if v := box.Values("errors.copy")["errors.copy"]; v != 3 {
+			t.Errorf("values, want: 3, got %d", v)
+		}
+		if c := box.Compare("errors.copy", "errors"); c != 0 {
+			t.Errorf("compare, want: 0, got %d", c)
+		}
+		if v, ok, acquired := box.TryPeekCounter("errors.copy"); v != 3 || !ok || !acquired {
+			t.Errorf("try peek, want: 3 true true, got %d %t %t", v, ok, acquired)
+		}
+		box.Transfer("errors", "errors.copy", 1)
+		if s := box.SnapshotAndReset(); s.Counters["errors.copy"] != 0 || s.Counters["total"] != 0 || s.Counters["errors"] != 2 {
+			t.Errorf("snapshot and reset, want: 2 and 2, got %v", s.Counters)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reading a computed counter which reads the box deadlocked")
+	}
+}
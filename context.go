@@ -0,0 +1,13 @@
+package counters
+
+import "context"
+
+// CountWithContext increments counter name if ctx is still active, or
+// counter name.timeout if ctx is already done, e.g. cancelled or past its
+// deadline. It returns the value of the incremented counter.
+func (c *CounterBox) CountWithContext(ctx context.Context, name string) int64 {
+	if ctx.Err() != nil {
+		return c.GetCounter(name + ".timeout").Increment()
+	}
+	return c.GetCounter(name).Increment()
+}
@@ -0,0 +1,23 @@
+package counters
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCountWithContext(t *testing.T) {
+	box := NewCounterBox()
+	box.CountWithContext(context.Background(), "op")
+	box.CountWithContext(context.Background(), "op")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	box.CountWithContext(ctx, "op")
+
+	if v := box.GetCounter("op").Value(); v != 2 {
+		t.Errorf("op, want: 2, got %d", v)
+	}
+	if v := box.GetCounter("op.timeout").Value(); v != 1 {
+		t.Errorf("op.timeout, want: 1, got %d", v)
+	}
+}
@@ -4,32 +4,50 @@
 package counters
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"fmt"
 	"io"
 	"math"
 	"net/http"
-	"os"
-	"os/signal"
+	"path"
+	"reflect"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
-	"syscall"
 	"text/template"
 	"time"
+	"unicode/utf8"
 )
 
 // MaxMinValue is an interface for minima and maxima counters.
 type MaxMinValue interface {
 	// Set allows to update value if necessary.
 	Set(int)
+	// SetDuration works like Set for a duration in nanoseconds.
+	SetDuration(d time.Duration)
+	// SetMany works like Set called for every value, but updates the value
+	// once with the extreme of values.
+	SetMany(values []int)
+	// ResetTo sets the value unconditionally, later updates are compared
+	// with it.
+	ResetTo(v int)
 	// Name returns a name of counter.
 	Name() string
 	// Value returns a current value.
 	Value() int64
+	// Kind returns KindMin or KindMax.
+	Kind() MetricKind
 }
 
-// Counter is an interface for integer increase only counter.
+// Counter is an interface for integer increase only counter. Updates return
+// the new value only for counters returned by GetCounter. A sharded counter,
+// see GetShardedCounter, returns the value of the updated shard, which is
+// a part of the value, so code using the returned value, e.g.
+// c.Increment() == 1, must not get its counter from GetShardedCounter.
 type Counter interface {
 	// Increment increases counter by one.
 	Increment() int64
@@ -39,12 +57,16 @@ type Counter interface {
 	Decrement() int64
 	// DecrementBy decreases counter by a given number.
 	DecrementBy(num int) int64
+	// IncrementByDuration increases counter by a duration in nanoseconds.
+	IncrementByDuration(d time.Duration) int64
 	// Set sets a specific value.
 	Set(num int)
 	// Name returns a name of counter.
 	Name() string
 	// Value returns a current value of counter.
 	Value() int64
+	// Kind returns KindCounter or KindGauge for computed counters.
+	Kind() MetricKind
 }
 
 type Counters interface {
@@ -55,7 +77,7 @@ type Counters interface {
 	GetCounter(string) Counter
 	GetMin(string) MaxMinValue
 	GetMax(string) MaxMinValue
-	WriteTo(w io.Writer)
+	WriteTo(w io.Writer) (int64, error)
 	Prefix() string
 	String() string
 }
@@ -63,27 +85,122 @@ type Counters interface {
 // CounterBox is a main type, it keeps references to all counters
 // requested from it.
 type CounterBox struct {
-	counters *sync.Map
-	min      *sync.Map
-	max      *sync.Map
+	counters     *sync.Map
+	min          *sync.Map
+	max          *sync.Map
+	groups       sync.Map
+	decaying     sync.Map
+	ratios       sync.Map
+	floats       sync.Map
+	floatMin     sync.Map
+	floatMax     sync.Map
+	nums         sync.Map
+	histograms   sync.Map
+	accumulators sync.Map
+	unique       sync.Map
+	rates        sync.Map
+	throttled    sync.Map
+	observations sync.Map
+	tallies      sync.Map
+	clock        Clock
+	// labels keeps *labeledName of counters created with labels.
+	labels sync.Map
+	// tags keeps map[string]string set by SetTags.
+	tags sync.Map
+	// constLabels are labels of every metric in exports.
+	constLabels map[string]string
+	// kinds keeps MetricKind of every name when strictNames is set.
+	kinds sync.Map
+
+	// label is rendered as a header by WriteTo.
+	label string
+	// outputPrefix is prepended to names in WriteTo, JSON and Prometheus
+	// output.
+	outputPrefix string
+	// humanReadable prints values with SI suffixes in WriteTo.
+	humanReadable bool
+	// alignedOutput aligns values in WriteTo output.
+	alignedOutput bool
+	// renderTime enables a footer with the time of rendering in WriteTo.
+	renderTime bool
+	// maxNameLen limits the length of counter names, 0 means no limit.
+	maxNameLen int
+	// maxOutputBytes limits the size of WriteTo output, 0 means no limit.
+	maxOutputBytes int
+	// creationTrace records where counters are created.
+	creationTrace bool
+	// updateTimes records the time of every update of counters.
+	updateTimes bool
+	// singleThreaded makes GetCounter create counters without atomic
+	// operations.
+	singleThreaded bool
+	// strictNames makes names unique across counters, min and max values.
+	strictNames bool
+	// shardedHistograms makes histograms spread observations over shards.
+	shardedHistograms bool
+	// timerUnit is a unit of durations recorded by Time and TimeErr,
+	// 0 means nanoseconds.
+	timerUnit time.Duration
+
+	resetMu sync.Mutex
+	onReset []func(name string)
+
+	marks markSet
+	// errorTypes are checked by CountErrorByType, sorted by name.
+	errorTypes []errorType
+	// markExpiry is how long marks set by Mark are kept.
+	markExpiry time.Duration
+
+	pushersMu sync.Mutex
+	pushers   []*pusherEntry
+
+	historyMu sync.Mutex
+	history   *snapshotRing
+	rollup    *snapshotRing
+	// historyStop stops taking snapshots, see EnableHistory.
+	historyStop func()
+	// rollupStop stops the running rollup, see StartRollup.
+	rollupStop func()
+
+	// mu guards operations which must see or modify several counters
+	// at once. Operations on a single counter don't use it.
+	mu sync.RWMutex
 }
 
 // NewCounterBox creates a new object to keep all counters.
-func NewCounterBox() *CounterBox {
-	return &CounterBox{
+func NewCounterBox(opts ...Option) *CounterBox {
+	c := &CounterBox{
 		counters: &sync.Map{},
 		min:      &sync.Map{},
 		max:      &sync.Map{},
+		clock:    realClock{},
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
 func New() Counters {
 	return NewCounterBox()
 }
 
+// ScrapeDuration is a name under which CreateHttpHandler records how long
+// it takes to print the counters, see Time.
+const ScrapeDuration = "counters.scrape.duration"
+
 // CreateHttpHandler creates a simple handler printing values of all counters.
+// With a nonzero=1 query parameter it skips counters with initial values,
+// see WriteToNonZero. Duration of every request is recorded under
+// ScrapeDuration.
 func (c *CounterBox) CreateHttpHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) { c.WriteTo(w) }
+	return func(w http.ResponseWriter, r *http.Request) {
+		write := c.WriteTo
+		if r.URL.Query().Get("nonzero") == "1" {
+			write = c.WriteToNonZero
+		}
+		c.Time(ScrapeDuration, func() { write(w) })
+	}
 }
 
 func (c *CounterBox) Get(name string) Counter {
@@ -157,102 +274,901 @@ func (c *prefixed) Prefix() string {
 	return c.prefix
 }
 
+func (c *prefixed) boxClock() Clock {
+	return c.base.clock
+}
+
+// checkName returns a name under which a counter is kept, it's the name
+// truncated to the limit set by WithMaxNameLen.
+func (c *CounterBox) checkName(name string) string {
+	if c.maxNameLen <= 0 || len(name) <= c.maxNameLen {
+		return name
+	}
+	n := c.maxNameLen
+	// Don't cut a multi-byte UTF-8 character.
+	for n > 0 && !utf8.RuneStart(name[n]) {
+		n--
+	}
+	return name[:n]
+}
+
+// claimName panics if strict names are enabled and name is already used
+// by a value of a different kind. A min and a max value may share a name.
+func (c *CounterBox) claimName(name string, kind MetricKind) {
+	if !c.strictNames {
+		return
+	}
+	prev, loaded := c.kinds.LoadOrStore(name, kind)
+	if loaded && (prev.(MetricKind) == KindCounter) != (kind == KindCounter) {
+		panic(fmt.Sprintf("counters: cannot use %q as %s, it's already used as %s", name, kind, prev))
+	}
+}
+
+// newCounter creates a counter with a given name and initial value.
+func (c *CounterBox) newCounter(name string, value int64) *counterImpl {
+	now := c.clock.Now().UnixNano()
+	v := &counterImpl{name: name, value: value, created: now, updated: now}
+	if c.updateTimes {
+		v.clock = c.clock
+	}
+	return v
+}
+
+// createCounter creates a counter for GetCounter, it records the code
+// creating the counter with WithCreationTrace.
+func (c *CounterBox) createCounter(name string) Counter {
+	if c.singleThreaded {
+		return &plainCounter{name: name}
+	}
+	v := c.newCounter(name, 0)
+	if c.creationTrace {
+		v.source = callerSource()
+	}
+	return v
+}
+
+// packageDir is the directory of the package files.
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return path.Dir(file)
+}()
+
+// callerSource returns file:line of the first caller outside the package,
+// so counters created by helpers, e.g. Count, get the code calling them.
+// Tests of the package count as outside.
+func callerSource() string {
+	pc := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(2, pc)])
+	for {
+		f, more := frames.Next()
+		if path.Dir(f.File) != packageDir || strings.HasSuffix(f.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
 // GetCounter returns a counter of given name, if doesn't exist than create.
 func (c *CounterBox) GetCounter(name string) Counter {
-	value, _ := c.counters.LoadOrStore(name, &counterImpl{name, 0})
+	name = c.checkName(name)
+	value, ok := c.counters.Load(name)
+	if !ok {
+		c.claimName(name, KindCounter)
+		value, _ = c.counters.LoadOrStore(name, c.createCounter(name))
+	}
 	v, _ := value.(Counter)
 	return v
 }
 
 // GetMin returns a minima counter of given name, if doesn't exist than create.
 func (c *CounterBox) GetMin(name string) MaxMinValue {
-	value, _ := c.min.LoadOrStore(name, &minImpl{name, math.MaxInt64})
+	name = c.checkName(name)
+	value, ok := c.min.Load(name)
+	if !ok {
+		c.claimName(name, KindMin)
+		value, _ = c.min.LoadOrStore(name, (*minImpl)(c.newCounter(name, math.MaxInt64)))
+	}
 	v, _ := value.(MaxMinValue)
 	return v
 }
 
 // GetMax returns a maxima counter of given name, if doesn't exist than create.
 func (c *CounterBox) GetMax(name string) MaxMinValue {
-	value, _ := c.max.LoadOrStore(name, &maxImpl{name, 0})
+	name = c.checkName(name)
+	value, ok := c.max.Load(name)
+	if !ok {
+		c.claimName(name, KindMax)
+		value, _ = c.max.LoadOrStore(name, (*maxImpl)(c.newCounter(name, 0)))
+	}
 	v, _ := value.(MaxMinValue)
 	return v
 }
 
-var tmpl = template.Must(template.New("main").Parse(`== Counters ==
+// Values returns values of counters of given names, counters which don't
+// exist are omitted. Values are keyed by the given names, also if they are
+// truncated, see WithMaxNameLen. All values are read under the box read
+// lock, so they are consistent with operations modifying several counters
+// at once (e.g. Group.ResetAll). Single counter updates aren't synchronized
+// with it.
+func (c *CounterBox) Values(names ...string) map[string]int64 {
+	values := make(map[string]int64, len(names))
+	computed := map[string]Counter{}
+	c.mu.RLock()
+	for _, name := range names {
+		if value, ok := c.counters.Load(c.checkName(name)); ok {
+			if v, ok := value.(Counter); ok {
+				if isComputed(v) {
+					computed[name] = v
+				} else {
+					values[name] = v.Value()
+				}
+			}
+		}
+	}
+	c.mu.RUnlock()
+	for name, v := range computed {
+		values[name] = v.Value()
+	}
+	return values
+}
+
+// Compare compares values of counters a and b, it returns -1 if a is lower,
+// 0 if they are equal and 1 if a is greater. A counter which doesn't exist
+// has value 0. Both values are read under the box read lock, so they are
+// consistent with operations modifying several counters at once, but not
+// with concurrent updates of single counters.
+func (c *CounterBox) Compare(a, b string) int {
+	v := c.Values(a, b)
+	va, vb := v[a], v[b]
+	switch {
+	case va < vb:
+		return -1
+	case va > vb:
+		return 1
+	}
+	return 0
+}
+
+// GrandTotal returns the sum of values of all counters. Computed counters,
+// min and max values aren't additive and are skipped. Values are read under
+// the box read lock, so the sum is consistent with operations modifying
+// several counters at once, e.g. Transfer.
+func (c *CounterBox) GrandTotal() int64 {
+	return c.GrandTotalByPrefix("")
+}
+
+// GrandTotalByPrefix works like GrandTotal for counters which names start
+// with prefix.
+func (c *CounterBox) GrandTotalByPrefix(prefix string) int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var sum int64
+	c.counters.Range(func(key interface{}, value interface{}) bool {
+		if v, ok := value.(Counter); ok && v.Kind() == KindCounter && strings.HasPrefix(key.(string), prefix) {
+			sum += v.Value()
+		}
+		return true
+	})
+	return sum
+}
+
+// Busiest returns a name and a value of the counter with the highest value,
+// of counters with equal values it returns the first by name. It reports
+// false if there are no counters. Values are read under the box read lock.
+func (c *CounterBox) Busiest() (name string, value int64, ok bool) {
+	return c.extreme(func(a, b int64) bool { return a > b })
+}
+
+// Quietest works like Busiest for the counter with the lowest value.
+func (c *CounterBox) Quietest() (name string, value int64, ok bool) {
+	return c.extreme(func(a, b int64) bool { return a < b })
+}
+
+// extreme returns the counter which value is better than values of all
+// other counters, ties are broken by name.
+func (c *CounterBox) extreme(better func(a, b int64) bool) (name string, value int64, ok bool) {
+	c.mu.RLock()
+	pairs := collectLocked(c.counters)
+	c.mu.RUnlock()
+	pairs = append(pairs, c.computedPairs()...)
+	for _, p := range pairs {
+		if !ok || better(p.Value, value) || (p.Value == value && p.Name < name) {
+			name, value, ok = p.Name, p.Value, true
+		}
+	}
+	return name, value, ok
+}
+
+// DeclareCounter creates counters of given names if they don't exist, so
+// they are visible in the output before they are first used.
+func (c *CounterBox) DeclareCounter(names ...string) {
+	for _, name := range names {
+		c.GetCounter(name)
+	}
+}
+
+// InitCounters creates counters of given names seeded with their initial
+// values. Counters which already exist are left untouched, so calling it
+// again doesn't overwrite values counted since. All counters are created
+// under the box write lock, operations reading several counters at once see
+// either none or all of them.
+func (c *CounterBox) InitCounters(initial map[string]int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, value := range initial {
+		name = c.checkName(name)
+		if _, ok := c.counters.Load(name); ok {
+			continue
+		}
+		c.claimName(name, KindCounter)
+		v := c.createCounter(name)
+		storeValue(v, value)
+		c.counters.LoadOrStore(name, v)
+	}
+}
+
+// DeclareMin creates minima counters of given names if they don't exist.
+func (c *CounterBox) DeclareMin(names ...string) {
+	for _, name := range names {
+		c.GetMin(name)
+	}
+}
+
+// DeclareMax creates maxima counters of given names if they don't exist.
+func (c *CounterBox) DeclareMax(names ...string) {
+	for _, name := range names {
+		c.GetMax(name)
+	}
+}
+
+// updater is implemented by counters which can be updated atomically
+// with a function.
+type updater interface {
+	update(fn func(old int64) int64) int64
+}
+
+// Update sets a counter of given name to fn(old) and returns the new value.
+// The function is applied in a compare-and-swap loop: if the counter was
+// modified concurrently fn is called again with the fresh value, so fn may
+// be called several times and should have no side effects. A sharded
+// counter applies fn under a lock, so updates with a function are atomic with
+// respect to each other, but fn may miss increments running concurrently.
+// Computed counters can't be updated, fn isn't called and their value is
+// returned.
+func (c *CounterBox) Update(name string, fn func(old int64) int64) int64 {
+	v := c.GetCounter(name)
+	if u, ok := v.(updater); ok {
+		return u.update(fn)
+	}
+	return v.Value()
+}
+
+// IncrementIfBelow increments a counter of given name only if its value is
+// below limit, the check and the increment are one atomic operation, see
+// Update. It returns the new value and whether the counter was incremented,
+// a computed counter is never incremented. It can be used e.g. to admit at
+// most limit concurrent operations which decrement the counter when they
+// finish.
+func (c *CounterBox) IncrementIfBelow(name string, limit int64) (int64, bool) {
+	var incremented bool
+	v := c.Update(name, func(old int64) int64 {
+		incremented = old < limit
+		if incremented {
+			return old + 1
+		}
+		return old
+	})
+	return v, incremented
+}
+
+// Transfer moves amount from counter from to counter to. Both counters are
+// updated under the box lock, so operations reading several counters at
+// once, e.g. Snapshot or Values, see the total of both unchanged. Reading
+// a single counter isn't synchronized with it and may see only one of
+// the updates.
+func (c *CounterBox) Transfer(from, to string, amount int64) {
+	src, dst := c.GetCounter(from), c.GetCounter(to)
+	c.mu.Lock()
+	// Updates of computed counters are ignored, but they read their value.
+	if !isComputed(src) {
+		src.DecrementBy(int(amount))
+	}
+	if !isComputed(dst) {
+		dst.IncrementBy(int(amount))
+	}
+	c.mu.Unlock()
+}
+
+func peek(m *sync.Map, name string) (int64, bool) {
+	value, ok := m.Load(name)
+	if !ok {
+		return 0, false
+	}
+	v, ok := value.(namedValue)
+	if !ok {
+		return 0, false
+	}
+	return v.Value(), true
+}
+
+// PeekCounter returns a value of a counter of given name, it doesn't create
+// the counter if it doesn't exist.
+func (c *CounterBox) PeekCounter(name string) (int64, bool) {
+	return peek(c.counters, c.checkName(name))
+}
+
+// PeekMin returns a value of a minima counter of given name, it doesn't
+// create the counter if it doesn't exist.
+func (c *CounterBox) PeekMin(name string) (int64, bool) {
+	return peek(c.min, c.checkName(name))
+}
+
+// PeekMax returns a value of a maxima counter of given name, it doesn't
+// create the counter if it doesn't exist.
+func (c *CounterBox) PeekMax(name string) (int64, bool) {
+	return peek(c.max, c.checkName(name))
+}
+
+// TryPeekCounter works like PeekCounter under the box read lock, but it
+// doesn't wait for the lock, e.g. in a signal handler. If an operation
+// modifying several counters at once, like Transfer, holds the lock, it
+// returns at once with acquired false and the other results are zero.
+func (c *CounterBox) TryPeekCounter(name string) (value int64, ok, acquired bool) {
+	if !c.mu.TryRLock() {
+		return 0, false, false
+	}
+	v, found := c.counters.Load(c.checkName(name))
+	if found && !isComputed(v) {
+		value, ok = c.PeekCounter(name)
+	}
+	c.mu.RUnlock()
+	if found && isComputed(v) {
+		value, ok = c.PeekCounter(name)
+	}
+	return value, ok, true
+}
+
+// CounterInfo describes a single counter.
+type CounterInfo struct {
+	Name      string
+	Value     int64
+	CreatedAt time.Time
+	// UpdatedAt is the time of the last modification, it equals CreatedAt
+	// for a counter which was never modified. It's recorded only with
+	// WithUpdateTimes, otherwise it's always CreatedAt.
+	UpdatedAt time.Time
+	// Source is file:line of the code which created the counter, it's set
+	// only with WithCreationTrace.
+	Source string
+}
+
+// Info returns information about a counter of given name. It doesn't
+// create a counter if it doesn't exist.
+func (c *CounterBox) Info(name string) (CounterInfo, bool) {
+	value, ok := c.counters.Load(c.checkName(name))
+	if !ok {
+		return CounterInfo{}, false
+	}
+	v, ok := value.(*counterImpl)
+	if !ok {
+		return CounterInfo{}, false
+	}
+	return CounterInfo{
+		Name:      v.name,
+		Value:     v.Value(),
+		CreatedAt: time.Unix(0, v.created),
+		UpdatedAt: time.Unix(0, atomic.LoadInt64(&v.updated)),
+		Source:    v.source,
+	}, true
+}
+
+// CreatedSince returns sorted names of counters created after t, e.g. to
+// find metrics introduced by a new deploy. Creation times are taken from
+// the box clock. Sharded, computed and single-threaded counters don't
+// record the creation time, so they are never returned.
+func (c *CounterBox) CreatedSince(t time.Time) []string {
+	var names []string
+	c.counters.Range(func(key interface{}, value interface{}) bool {
+		if v, ok := value.(*counterImpl); ok && time.Unix(0, v.created).After(t) {
+			names = append(names, v.name)
+		}
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
+
+// tmplText is a template of WriteTo output.
+const tmplText = `
+{{- if .Label}}=== {{.Label}} ===
+{{end -}}
+== Counters ==
 {{- range .Counters}}
   {{.Name}}: {{.Value}}
 {{- end}}
+{{- if .CountersOmitted}}
+  ... ({{.CountersOmitted}} more)
+{{- end}}
 == Min values ==
 {{- range .Min}}
   {{.Name}}: {{.Value}}
 {{- end}}
+{{- if .MinOmitted}}
+  ... ({{.MinOmitted}} more)
+{{- end}}
 == Max values ==
 {{- range .Max}}
   {{.Name}}: {{.Value}}
+{{- end}}
+{{- if .MaxOmitted}}
+  ... ({{.MaxOmitted}} more)
+{{- end}}
+{{- if .Accumulators}}
+== Accumulators ==
+{{- range .Accumulators}}
+  {{.Name}}: {{.Value}}
+{{- end}}
+{{- end}}
+{{- if .Floats}}
+== Float counters ==
+{{- range .Floats}}
+  {{.Name}}: {{.Text}}
+{{- end}}
+{{- end}}
+{{- if .FloatMin}}
+== Float min values ==
+{{- range .FloatMin}}
+  {{.Name}}: {{.Text}}
+{{- end}}
+{{- end}}
+{{- if .FloatMax}}
+== Float max values ==
+{{- range .FloatMax}}
+  {{.Name}}: {{.Text}}
+{{- end}}
+{{- end}}
+{{- if .Ratios}}
+== Ratios ==
+{{- range .Ratios}}
+  {{.Name}}: {{printf "%.4f" .Ratio}}
+{{- end}}
+{{- end}}
+{{- if .RenderedAt}}
+== Rendered at {{.RenderedAt}} ==
 {{- end -}}
-`))
-
-func (c *CounterBox) WriteTo(w io.Writer) {
-	data := &struct {
-		Counters []Counter
-		Min      []MaxMinValue
-		Max      []MaxMinValue
-	}{}
-	c.counters.Range(func(key interface{}, value interface{}) bool {
-		if value, ok := value.(Counter); ok {
-			data.Counters = append(data.Counters, value)
+`
+
+var tmpl = template.Must(template.New("main").Parse(tmplText))
+
+// humanTmpl is tmpl printing values with SI suffixes, see WithHumanReadable.
+// Min values which were never set are printed as they are.
+var humanTmpl = template.Must(template.New("main").
+	Funcs(FuncMap()).
+	Funcs(template.FuncMap{"siMin": siMin}).
+	Parse(strings.ReplaceAll(strings.Replace(tmplText,
+		"{{- range .Min}}\n  {{.Name}}: {{.Value}}",
+		"{{- range .Min}}\n  {{.Name}}: {{siMin .Value}}", 1),
+		"{{.Value}}", "{{si .Value}}")))
+
+// CounterPair is a name and a value of a single counter.
+type CounterPair struct {
+	Name  string
+	Value int64
+}
+
+type namedValue interface {
+	Name() string
+	Value() int64
+}
+
+// collectPairs returns values kept in m as pairs in the map order.
+func collectPairs(m *sync.Map) []CounterPair {
+	var pairs []CounterPair
+	m.Range(func(key interface{}, value interface{}) bool {
+		if value, ok := value.(namedValue); ok {
+			pairs = append(pairs, CounterPair{value.Name(), value.Value()})
 		}
 		return true
 	})
-	c.min.Range(func(key interface{}, value interface{}) bool {
-		if value, ok := value.(MaxMinValue); ok {
-			data.Min = append(data.Min, value)
+	return pairs
+}
+
+// sortedPairs returns values kept in m as pairs sorted by name.
+func sortedPairs(m *sync.Map) []CounterPair {
+	pairs := collectPairs(m)
+	sort.Slice(pairs, func(i, j int) bool { return strings.Compare(pairs[i].Name, pairs[j].Name) < 0 })
+	return pairs
+}
+
+// Pairs returns names and values of all counters sorted by name.
+func (c *CounterBox) Pairs() []CounterPair {
+	return sortedPairs(c.counters)
+}
+
+// MinPairs returns names and values of all minima counters sorted by name.
+func (c *CounterBox) MinPairs() []CounterPair {
+	return sortedPairs(c.min)
+}
+
+// MaxPairs returns names and values of all maxima counters sorted by name.
+func (c *CounterBox) MaxPairs() []CounterPair {
+	return sortedPairs(c.max)
+}
+
+// RenderData is the data rendered by WriteTo, it can be used to render a box
+// with a custom template or directly. Names have the prefix set by
+// WithOutputPrefix. Slices are empty if the box has no such values.
+type RenderData struct {
+	// Label is set by WithLabel.
+	Label        string
+	Counters     []CounterPair
+	Min          []CounterPair
+	Max          []CounterPair
+	Accumulators []CounterPair
+	Floats       []FloatPair
+	FloatMin     []FloatPair
+	FloatMax     []FloatPair
+	Ratios       []Ratio
+	// RenderedAt is the time of rendering in RFC 3339 format if
+	// WithRenderTime is set.
+	RenderedAt string
+	// CountersOmitted, MinOmitted and MaxOmitted are numbers of values
+	// left out by WriteToLimited.
+	CountersOmitted int
+	MinOmitted      int
+	MaxOmitted      int
+}
+
+// RenderData returns values of the box as they are rendered by WriteTo,
+// every slice is sorted by name.
+func (c *CounterBox) RenderData() RenderData {
+	return *c.newRenderData(c.Pairs(), c.MinPairs(), c.MaxPairs())
+}
+
+func (c *CounterBox) newRenderData(counters, min, max []CounterPair) *RenderData {
+	p := c.outputPrefix
+	data := &RenderData{
+		Label:        c.label,
+		Counters:     prefixPairs(p, counters),
+		Min:          prefixPairs(p, min),
+		Max:          prefixPairs(p, max),
+		Accumulators: prefixPairs(p, c.AccumulatorPairs()),
+		Floats:       prefixFloatPairs(p, c.FloatPairs()),
+		FloatMin:     prefixFloatPairs(p, c.FloatMinPairs()),
+		FloatMax:     prefixFloatPairs(p, c.FloatMaxPairs()),
+		Ratios:       prefixRatios(p, c.sortedRatios()),
+	}
+	if c.renderTime {
+		data.RenderedAt = c.clock.Now().Format(time.RFC3339)
+	}
+	return data
+}
+
+// prefixPairs returns pairs with names prefixed with prefix.
+func prefixPairs(prefix string, pairs []CounterPair) []CounterPair {
+	if prefix == "" {
+		return pairs
+	}
+	prefixed := make([]CounterPair, len(pairs))
+	for i, p := range pairs {
+		prefixed[i] = CounterPair{prefix + p.Name, p.Value}
+	}
+	return prefixed
+}
+
+// prefixFloatPairs returns pairs with names prefixed with prefix.
+func prefixFloatPairs(prefix string, pairs []FloatPair) []FloatPair {
+	if prefix == "" {
+		return pairs
+	}
+	prefixed := make([]FloatPair, len(pairs))
+	for i, p := range pairs {
+		p.Name = prefix + p.Name
+		prefixed[i] = p
+	}
+	return prefixed
+}
+
+// alignValues pads value lines of out, "  name: value", so all values end
+// in the same column.
+func alignValues(out []byte) []byte {
+	lines := bytes.Split(out, []byte("\n"))
+	nameWidth, valueWidth := 0, 0
+	for _, l := range lines {
+		if i := bytes.LastIndex(l, []byte(": ")); i >= 0 && bytes.HasPrefix(l, []byte("  ")) {
+			nameWidth = max(nameWidth, i+1)
+			valueWidth = max(valueWidth, len(l)-i-2)
 		}
-		return true
-	})
-	c.max.Range(func(key interface{}, value interface{}) bool {
-		if value, ok := value.(MaxMinValue); ok {
-			data.Max = append(data.Max, value)
+	}
+	aligned := make([]byte, 0, len(out)+len(lines)*(nameWidth+valueWidth))
+	for n, l := range lines {
+		if n > 0 {
+			aligned = append(aligned, '\n')
 		}
-		return true
-	})
-	sort.Slice(data.Counters, func(i, j int) bool { return strings.Compare(data.Counters[i].Name(), data.Counters[j].Name()) < 0 })
-	sort.Slice(data.Min, func(i, j int) bool { return strings.Compare(data.Min[i].Name(), data.Min[j].Name()) < 0 })
-	sort.Slice(data.Max, func(i, j int) bool { return strings.Compare(data.Max[i].Name(), data.Max[j].Name()) < 0 })
-	tmpl.Execute(w, data)
+		i := bytes.LastIndex(l, []byte(": "))
+		if i < 0 || !bytes.HasPrefix(l, []byte("  ")) {
+			aligned = append(aligned, l...)
+			continue
+		}
+		aligned = append(aligned, l[:i+1]...)
+		for pad := nameWidth + 1 + valueWidth - (i + 1) - (len(l) - i - 2); pad > 0; pad-- {
+			aligned = append(aligned, ' ')
+		}
+		aligned = append(aligned, l[i+2:]...)
+	}
+	return aligned
+}
+
+// render writes data rendered with tmpl, or humanTmpl if WithHumanReadable
+// is set, to w. With WithAlignedOutput values are aligned. If the output is
+// longer than the limit set by WithMaxOutputBytes it's cut after the last
+// full line which fits and a line with the number of omitted bytes is
+// appended.
+func (c *CounterBox) render(w io.Writer, data *RenderData) (int64, error) {
+	t := tmpl
+	if c.humanReadable {
+		t = humanTmpl
+	}
+	if c.maxOutputBytes <= 0 && !c.alignedOutput {
+		return execute(w, t, data)
+	}
+	buf := &bytes.Buffer{}
+	_, err := execute(buf, t, data)
+	out := buf.Bytes()
+	if c.alignedOutput {
+		out = alignValues(out)
+	}
+	if c.maxOutputBytes > 0 && len(out) > c.maxOutputBytes {
+		n := bytes.LastIndexByte(out[:c.maxOutputBytes+1], '\n')
+		sep := "\n"
+		if n < 0 {
+			n, sep = 0, ""
+		}
+		out = fmt.Appendf(out[:n:n], "%s... (truncated, %d more bytes)", sep, len(out)-n)
+	}
+	n, werr := w.Write(out)
+	if err == nil {
+		err = werr
+	}
+	return int64(n), err
 }
 
+// countingWriter counts bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// execute renders data with t into w, it returns the number of bytes written
+// and the first error of writing or template execution. The template writes
+// many small chunks, so unless w keeps data in memory they are buffered and
+// w gets a few big writes.
+func execute(w io.Writer, t *template.Template, data interface{}) (int64, error) {
+	cw := &countingWriter{w: w}
+	switch w.(type) {
+	case *bytes.Buffer, *strings.Builder, *appendWriter, *bufio.Writer:
+		err := t.Execute(cw, data)
+		return cw.n, err
+	}
+	bw := bufio.NewWriter(cw)
+	err := t.Execute(bw, data)
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return cw.n, err
+}
+
+// WriteTo implements io.WriterTo, it prints values of all counters sorted
+// by name.
+func (c *CounterBox) WriteTo(w io.Writer) (int64, error) {
+	return c.render(w, c.newRenderData(c.Pairs(), c.MinPairs(), c.MaxPairs()))
+}
+
+// WriteToUnsorted works like WriteTo but skips sorting, which is faster for
+// big boxes. The order of counters in the output isn't deterministic.
+func (c *CounterBox) WriteToUnsorted(w io.Writer) (int64, error) {
+	return c.render(w, c.newRenderData(collectPairs(c.counters), collectPairs(c.min), collectPairs(c.max)))
+}
+
+// Stream sends all counters sorted by name on the returned channel. Values
+// are read under the box read lock before sending starts. The channel is
+// closed when all counters were sent or ctx is done.
+func (c *CounterBox) Stream(ctx context.Context) <-chan CounterPair {
+	c.mu.RLock()
+	pairs := collectLocked(c.counters)
+	c.mu.RUnlock()
+	pairs = append(pairs, c.computedPairs()...)
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	ch := make(chan CounterPair)
+	go func() {
+		defer close(ch)
+		for _, p := range pairs {
+			select {
+			case ch <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// withoutValue returns pairs which value is different from seed.
+func withoutValue(pairs []CounterPair, seed int64) []CounterPair {
+	var filtered []CounterPair
+	for _, p := range pairs {
+		if p.Value != seed {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// WriteToNonZero works like WriteTo but skips counters which still have
+// their initial value: 0 for counters and maxima, math.MaxInt64 for minima.
+func (c *CounterBox) WriteToNonZero(w io.Writer) (int64, error) {
+	return c.render(w, c.newRenderData(
+		withoutValue(c.Pairs(), 0),
+		withoutValue(c.MinPairs(), math.MaxInt64),
+		withoutValue(c.MaxPairs(), 0)))
+}
+
+// limitPairs returns at most n first pairs and the number of the other ones.
+func limitPairs(pairs []CounterPair, n int) ([]CounterPair, int) {
+	if len(pairs) <= n {
+		return pairs, 0
+	}
+	return pairs[:n], len(pairs) - n
+}
+
+// WriteToLimited works like WriteTo but prints at most perSection first
+// counters, min and max values each, e.g. to keep periodic log dumps
+// bounded. A section with more values ends with a line with the number of
+// values left out, like "  ... (12 more)".
+func (c *CounterBox) WriteToLimited(w io.Writer, perSection int) (int64, error) {
+	if perSection < 0 {
+		perSection = 0
+	}
+	counters, countersOmitted := limitPairs(c.Pairs(), perSection)
+	min, minOmitted := limitPairs(c.MinPairs(), perSection)
+	max, maxOmitted := limitPairs(c.MaxPairs(), perSection)
+	data := c.newRenderData(counters, min, max)
+	data.CountersOmitted, data.MinOmitted, data.MaxOmitted = countersOmitted, minOmitted, maxOmitted
+	return c.render(w, data)
+}
+
+// appendWriter is an io.Writer appending everything to a byte slice.
+type appendWriter []byte
+
+func (a *appendWriter) Write(p []byte) (int, error) {
+	*a = append(*a, p...)
+	return len(p), nil
+}
+
+// AppendTo appends the same output as WriteTo to dst and returns
+// the extended slice.
+func (c *CounterBox) AppendTo(dst []byte) []byte {
+	a := appendWriter(dst)
+	c.WriteTo(&a)
+	return a
+}
+
+// String returns the output of WriteTo. If rendering fails, the error is
+// appended to the output rendered so far.
 func (c *CounterBox) String() string {
 	buf := &bytes.Buffer{}
-	c.WriteTo(buf)
+	if _, err := c.WriteTo(buf); err != nil {
+		fmt.Fprintf(buf, "\n!(ERROR: %v)", err)
+	}
 	return buf.String()
 }
 
 type counterImpl struct {
 	name  string
 	value int64
+	// resetEpoch is the number of resets of a counter, see ResetEpoch.
+	// 64-bit values updated atomically are kept first, so they are 8-byte
+	// aligned also on 32-bit platforms.
+	resetEpoch int64
+	// clock is nil unless the box records the time of updates, see
+	// WithUpdateTimes.
+	clock Clock
+	// created and updated are in nanoseconds since epoch.
+	created int64
+	updated int64
+	// source is file:line of the code which created the counter, it's set
+	// only with WithCreationTrace.
+	source string
+	// disabled is not 0 if updates are ignored, see Disable.
+	disabled int32
+}
+
+// isDisabled reports whether updates of the counter are ignored.
+func (c *counterImpl) isDisabled() bool {
+	return atomic.LoadInt32(&c.disabled) != 0
+}
+
+// touch records the time of modification.
+func (c *counterImpl) touch() {
+	if c.clock != nil {
+		atomic.StoreInt64(&c.updated, c.clock.Now().UnixNano())
+	}
 }
 
 func (c *counterImpl) Increment() int64 {
+	if c.isDisabled() {
+		return c.Value()
+	}
+	c.touch()
 	return atomic.AddInt64(&c.value, 1)
 }
 
 func (c *counterImpl) IncrementBy(num int) int64 {
+	if c.isDisabled() {
+		return c.Value()
+	}
+	c.touch()
 	return atomic.AddInt64(&c.value, int64(num))
 }
 
 func (c *counterImpl) Decrement() int64 {
+	if c.isDisabled() {
+		return c.Value()
+	}
+	c.touch()
 	return atomic.AddInt64(&c.value, -1)
 }
 
 func (c *counterImpl) DecrementBy(num int) int64 {
+	if c.isDisabled() {
+		return c.Value()
+	}
+	c.touch()
 	return atomic.AddInt64(&c.value, -int64(num))
 }
 
+func (c *counterImpl) IncrementByDuration(d time.Duration) int64 {
+	if c.isDisabled() {
+		return c.Value()
+	}
+	c.touch()
+	return atomic.AddInt64(&c.value, int64(d))
+}
+
 func (c *counterImpl) Set(num int) {
+	if c.isDisabled() {
+		return
+	}
+	c.touch()
 	atomic.StoreInt64(&c.value, int64(num))
 }
 
+func (c *counterImpl) update(fn func(old int64) int64) int64 {
+	if c.isDisabled() {
+		return c.Value()
+	}
+	for {
+		o := atomic.LoadInt64(&c.value)
+		n := fn(o)
+		if atomic.CompareAndSwapInt64(&c.value, o, n) {
+			c.touch()
+			return n
+		}
+	}
+}
+
+func (c *counterImpl) Kind() MetricKind {
+	return KindCounter
+}
+
 func (c *counterImpl) Name() string {
 	return c.name
 }
@@ -261,11 +1177,43 @@ func (c *counterImpl) Value() int64 {
 	return atomic.LoadInt64(&c.value)
 }
 
+func (c *counterImpl) reset() {
+	atomic.StoreInt64(&c.value, 0)
+	atomic.AddInt64(&c.resetEpoch, 1)
+}
+
+func (c *counterImpl) epoch() int64 {
+	return atomic.LoadInt64(&c.resetEpoch)
+}
+
 type maxImpl counterImpl
 
 func (m *maxImpl) Set(v int) {
+	m.set(int64(v))
+}
+
+func (m *maxImpl) SetDuration(d time.Duration) {
+	m.set(int64(d))
+}
+
+func (m *maxImpl) SetMany(values []int) {
+	if len(values) == 0 {
+		return
+	}
+	max := values[0]
+	for _, v := range values[1:] {
+		if v > max {
+			max = v
+		}
+	}
+	m.set(int64(max))
+}
+
+func (m *maxImpl) set(v64 int64) {
+	if (*counterImpl)(m).isDisabled() {
+		return
+	}
 	done := false
-	v64 := int64(v)
 	for !done {
 		if o := atomic.LoadInt64(&m.value); v64 > o {
 			done = atomic.CompareAndSwapInt64(&m.value, o, v64)
@@ -275,6 +1223,18 @@ func (m *maxImpl) Set(v int) {
 	}
 }
 
+func (m *maxImpl) ResetTo(v int) {
+	m.store(int64(v))
+}
+
+func (m *maxImpl) reset() {
+	atomic.StoreInt64(&m.value, 0)
+}
+
+func (m *maxImpl) Kind() MetricKind {
+	return KindMax
+}
+
 func (m *maxImpl) Name() string {
 	return m.name
 }
@@ -286,8 +1246,31 @@ func (m *maxImpl) Value() int64 {
 type minImpl counterImpl
 
 func (m *minImpl) Set(v int) {
+	m.set(int64(v))
+}
+
+func (m *minImpl) SetDuration(d time.Duration) {
+	m.set(int64(d))
+}
+
+func (m *minImpl) SetMany(values []int) {
+	if len(values) == 0 {
+		return
+	}
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+	m.set(int64(min))
+}
+
+func (m *minImpl) set(v64 int64) {
+	if (*counterImpl)(m).isDisabled() {
+		return
+	}
 	done := false
-	v64 := int64(v)
 	for !done {
 		if o := atomic.LoadInt64(&m.value); v64 < o {
 			done = atomic.CompareAndSwapInt64(&m.value, o, v64)
@@ -297,6 +1280,18 @@ func (m *minImpl) Set(v int) {
 	}
 }
 
+func (m *minImpl) ResetTo(v int) {
+	m.store(int64(v))
+}
+
+func (m *minImpl) reset() {
+	atomic.StoreInt64(&m.value, math.MaxInt64)
+}
+
+func (m *minImpl) Kind() MetricKind {
+	return KindMin
+}
+
 func (m *minImpl) Name() string {
 	return m.name
 }
@@ -309,27 +1304,118 @@ type TrivialLogger interface {
 	Print(...interface{})
 }
 
-func InitCountersOnSignal(logger TrivialLogger, box Counters) {
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+func LogCountersEvery(logger TrivialLogger, box Counters, d time.Duration) {
 	go func() {
-		lastInt := time.Now()
-		for sig := range sigs {
+		t := time.NewTicker(d)
+		for range t.C {
 			logger.Print(box.String())
-			l := time.Now()
-			if sig == syscall.SIGTERM || l.Sub(lastInt).Seconds() < 1. {
-				os.Exit(0)
+		}
+	}()
+}
+
+// OnTick calls fn with the box every d until the returned function is
+// called, e.g. to push values to a custom exporter. It uses the box clock,
+// like the periodic loggers. Calls don't overlap, a slow fn delays the next
+// ones.
+func (c *CounterBox) OnTick(d time.Duration, fn func(*CounterBox)) (stop func()) {
+	return c.every(d, func() { fn(c) })
+}
+
+// DumpOnPanic logs values of all counters if the goroutine panics,
+// then it continues panicking with the original value. It must be deferred
+// directly:
+//
+//	defer box.DumpOnPanic(logger)
+func (c *CounterBox) DumpOnPanic(logger TrivialLogger) {
+	if r := recover(); r != nil {
+		logger.Print(c.String())
+		panic(r)
+	}
+}
+
+func (c *CounterBox) boxClock() Clock {
+	return c.clock
+}
+
+// clockOf returns a clock used by box.
+func clockOf(box Counters) Clock {
+	if c, ok := box.(interface{ boxClock() Clock }); ok {
+		return c.boxClock()
+	}
+	return realClock{}
+}
+
+// state returns a value describing the current values of box, it's
+// comparable with reflect.DeepEqual.
+func state(box Counters) interface{} {
+	if s, ok := box.(interface{ Snapshot() CounterSnapshot }); ok {
+		return s.Snapshot()
+	}
+	return box.String()
+}
+
+// LogCountersEveryOnChange works like LogCountersEvery but logs only if
+// any value changed since the previous tick. It uses the box clock.
+func LogCountersEveryOnChange(logger TrivialLogger, box Counters, d time.Duration) {
+	t := clockOf(box).NewTicker(d)
+	go func() {
+		prev := state(box)
+		for range t.C() {
+			if cur := state(box); !reflect.DeepEqual(cur, prev) {
+				logger.Print(box.String())
+				prev = cur
 			}
-			lastInt = l
 		}
 	}()
 }
 
-func LogCountersEvery(logger TrivialLogger, box Counters, d time.Duration) {
+// LogCountersDeltaEvery logs every d how much each counter changed since
+// the previous tick together with its current value, e.g.
+//
+//	== Counter deltas ==
+//	  requests: +12 (1034)
+//
+// Deltas of the first tick are counted from 0. Min and max values are not
+// logged. It uses the box clock.
+func LogCountersDeltaEvery(logger TrivialLogger, box Box, d time.Duration) {
+	t := clockOf(box).NewTicker(d)
 	go func() {
-		t := time.NewTicker(d)
-		for range t.C {
-			logger.Print(box.String())
+		prev := map[string]int64{}
+		for range t.C() {
+			cur := box.Snapshot().Counters
+			logger.Print(formatDeltas(cur, prev))
+			prev = cur
 		}
 	}()
 }
+
+// formatDeltas returns a text with sorted counters of cur and their change
+// since prev.
+func formatDeltas(cur, prev map[string]int64) string {
+	var b strings.Builder
+	b.WriteString("== Counter deltas ==")
+	for _, name := range sortedNames(cur) {
+		fmt.Fprintf(&b, "\n  %s: %+d (%d)", name, cur[name]-prev[name], cur[name])
+	}
+	return b.String()
+}
+
+// LogOnSpike logs a line when the rate of counter name exceeds ratePerSec.
+// The rate is measured every second with the box clock. After logging it
+// doesn't log again for cooldown, so a long spike doesn't flood the log.
+// It returns a function which stops the checks.
+func (c *CounterBox) LogOnSpike(logger TrivialLogger, name string, ratePerSec float64, cooldown time.Duration) (stop func()) {
+	prev, _ := c.PeekCounter(name)
+	prevTime := c.clock.Now()
+	var logged time.Time
+	return c.every(time.Second, func() {
+		now := c.clock.Now()
+		v, _ := c.PeekCounter(name)
+		rate := float64(v-prev) / now.Sub(prevTime).Seconds()
+		prev, prevTime = v, now
+		if rate > ratePerSec && (logged.IsZero() || now.Sub(logged) >= cooldown) {
+			logged = now
+			logger.Print(fmt.Sprintf("counter %s spikes at %.1f/s, above %.1f/s", name, rate, ratePerSec))
+		}
+	})
+}
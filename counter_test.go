@@ -1,8 +1,18 @@
 package counters
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"text/template"
+	"time"
 )
 
 func TestWriteTo(t *testing.T) {
@@ -11,6 +21,128 @@ func TestWriteTo(t *testing.T) {
 	fmt.Println(box.String())
 }
 
+func TestWriteToOutput(t *testing.T) {
+	clock := newFakeClock()
+	fill := func(box *CounterBox) {
+		box.GetCounter("a").IncrementBy(2)
+		box.GetMin("b").Set(3)
+		box.GetMax("c").Set(4)
+	}
+	body := `== Counters ==
+  a: 2
+== Min values ==
+  b: 3
+== Max values ==
+  c: 4`
+
+	box := NewCounterBox(WithClock(clock))
+	fill(box)
+	if got := box.String(); got != body {
+		t.Errorf("unlabeled, want:\n%s\ngot:\n%s", body, got)
+	}
+
+	box = NewCounterBox(WithClock(clock), WithLabel("worker"), WithRenderTime())
+	fill(box)
+	want := "=== worker ===\n" + body + "\n== Rendered at 2020-01-01T00:00:00Z =="
+	if got := box.String(); got != want {
+		t.Errorf("labeled, want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+// failingWriter accepts limit bytes and fails afterwards.
+type failingWriter struct {
+	limit int
+}
+
+var errWrite = errors.New("write failed")
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > f.limit {
+		n := f.limit
+		f.limit = 0
+		return n, errWrite
+	}
+	f.limit -= len(p)
+	return len(p), nil
+}
+
+// callCounter counts calls of Write.
+type callCounter struct {
+	calls int
+}
+
+func (c *callCounter) Write(p []byte) (int, error) {
+	c.calls++
+	return len(p), nil
+}
+
+func TestWriteToBuffered(t *testing.T) {
+	box := NewCounterBox()
+	for i := 0; i < 100; i++ {
+		box.GetCounter(fmt.Sprintf("counter.%d", i)).Increment()
+	}
+	// Every counter takes a few template writes without buffering.
+	unbuffered := &callCounter{}
+	if err := tmpl.Execute(unbuffered, box.newRenderData(box.Pairs(), nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+	w := &callCounter{}
+	n, err := box.WriteTo(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unbuffered.calls < 100 {
+		t.Errorf("unbuffered, want at least 100 writes, got %d", unbuffered.calls)
+	}
+	if want := int(n/4096) + 1; w.calls > want {
+		t.Errorf("want at most %d writes of %d bytes, got %d", want, n, w.calls)
+	}
+}
+
+func TestWriteToError(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("test").Increment()
+	full := box.String()
+
+	n, err := box.WriteTo(&failingWriter{limit: 20})
+	if err == nil {
+		t.Errorf("WriteTo should return the write error")
+	}
+	if n != 20 {
+		t.Errorf("want 20 bytes written, got %d", n)
+	}
+	n, err = box.WriteTo(io.Discard)
+	if err != nil || n != int64(len(full)) {
+		t.Errorf("want %d bytes and no error, got %d and %v", len(full), n, err)
+	}
+}
+
+func TestStringTemplateError(t *testing.T) {
+	defer func(t *template.Template) { tmpl = t }(tmpl)
+	tmpl = template.Must(template.New("broken").Parse(`partial{{range .Counters}}{{.Missing}}{{end}}`))
+	box := NewCounterBox()
+	box.GetCounter("test").Increment()
+
+	if _, err := box.WriteTo(io.Discard); err == nil {
+		t.Errorf("WriteTo should return the template error")
+	}
+	s := box.String()
+	if !strings.HasPrefix(s, "partial") || !strings.Contains(s, "!(ERROR: ") {
+		t.Errorf("String should contain partial output and the error, got %q", s)
+	}
+}
+
+func TestWriteToUnsorted(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("a").IncrementBy(2)
+	box.GetMin("b").Set(3)
+	buf := &strings.Builder{}
+	box.WriteToUnsorted(buf)
+	if got, want := buf.String(), box.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
 func TestIncrement(t *testing.T) {
 	box := NewCounterBox()
 	cnt := box.GetCounter("test")
@@ -57,6 +189,139 @@ func TestMax(t *testing.T) {
 	}
 }
 
+func TestDurations(t *testing.T) {
+	box := NewCounterBox()
+	durations := []time.Duration{1500 * time.Microsecond, 50*time.Hour + 7, 250 * time.Millisecond}
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+		if v := box.GetCounter("total").IncrementByDuration(d); v != int64(total) {
+			t.Errorf("IncrementByDuration, want: %d, got %d", total, v)
+		}
+		box.GetMax("max").SetDuration(d)
+		box.GetMin("min").SetDuration(d)
+	}
+	if v := box.GetMax("max").Value(); v != int64(50*time.Hour+7) {
+		t.Errorf("max, want: %d, got %d", 50*time.Hour+7, v)
+	}
+	if v := box.GetMin("min").Value(); v != int64(1500*time.Microsecond) {
+		t.Errorf("min, want: %d, got %d", 1500*time.Microsecond, v)
+	}
+}
+
+func TestResetTo(t *testing.T) {
+	box := NewCounterBox()
+	max := box.GetMax("max")
+	max.Set(100)
+	max.ResetTo(40)
+	max.Set(30)
+	if v := max.Value(); v != 40 {
+		t.Errorf("max after lower Set, want: 40, got %d", v)
+	}
+	max.Set(50)
+	if v := max.Value(); v != 50 {
+		t.Errorf("max after higher Set, want: 50, got %d", v)
+	}
+
+	min := box.GetMin("min")
+	min.Set(1)
+	min.ResetTo(40)
+	min.Set(50)
+	if v := min.Value(); v != 40 {
+		t.Errorf("min after higher Set, want: 40, got %d", v)
+	}
+	min.Set(30)
+	if v := min.Value(); v != 30 {
+		t.Errorf("min after lower Set, want: 30, got %d", v)
+	}
+}
+
+func TestMaxNameLen(t *testing.T) {
+	box := NewCounterBox(WithMaxNameLen(8))
+	box.GetCounter("http.requests./users?id=1").Increment()
+	box.GetCounter("http.requests./users?id=2").Increment()
+	box.GetMax("short").Set(3)
+	box.GetMin("abcżółć").Set(4)
+
+	want := []CounterPair{{"http.req", 2}}
+	if got := box.Pairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+	if v, ok := box.PeekCounter("http.requests"); !ok || v != 2 {
+		t.Errorf("PeekCounter should find the truncated name, got %d, %t", v, ok)
+	}
+	if v, ok := box.PeekMax("short"); !ok || v != 3 {
+		t.Errorf("short names should not change, got %d, %t", v, ok)
+	}
+	if got, want := box.Values("http.requests"), map[string]int64{"http.requests": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Values, want: %v, got %v", want, got)
+	}
+	if info, ok := box.Info("http.requests./users?id=3"); !ok || info.Name != "http.req" {
+		t.Errorf("Info should find the truncated name, got %+v, %t", info, ok)
+	}
+	box.RegisterComputed("computed.long", func(*CounterBox) int64 { return 5 })
+	if v, ok := box.PeekCounter("computed.long"); !ok || v != 5 {
+		t.Errorf("RegisterComputed should truncate the name, got %d, %t", v, ok)
+	}
+	if v := box.GetCounter("computed.other").Value(); v != 5 {
+		t.Errorf("GetCounter should find the computed counter, want: 5, got %d", v)
+	}
+	box.GetAccumulator("accumulated.long").Add(1)
+	if got := box.GetAccumulator("accumulated").Value(); got != 1 {
+		t.Errorf("GetAccumulator should find the truncated name, want: 1, got %d", got)
+	}
+	// The multi-byte character at the limit is dropped as a whole.
+	want = []CounterPair{{"abcżó", 4}}
+	if got := box.MinPairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+}
+
+func TestDeclare(t *testing.T) {
+	box := NewCounterBox()
+	box.DeclareCounter("requests", "errors")
+	box.DeclareMax("latency")
+	box.DeclareMin("latency")
+	box.GetCounter("errors").Increment()
+	box.DeclareCounter("errors")
+
+	want := `== Counters ==
+  errors: 1
+  requests: 0
+== Min values ==
+  latency: 9223372036854775807
+== Max values ==
+  latency: 0`
+	if got := box.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestSetMany(t *testing.T) {
+	box := NewCounterBox()
+	max := box.GetMax("max")
+	max.Set(10)
+	max.SetMany([]int{3, 8, 5})
+	if v := max.Value(); v != 10 {
+		t.Errorf("max below prior value, want: 10, got %d", v)
+	}
+	max.SetMany([]int{3, 12, 5})
+	if v := max.Value(); v != 12 {
+		t.Errorf("max, want: 12, got %d", v)
+	}
+	max.SetMany(nil)
+
+	min := box.GetMin("min")
+	min.SetMany([]int{7, -2, 4})
+	if v := min.Value(); v != -2 {
+		t.Errorf("min, want: -2, got %d", v)
+	}
+	min.SetMany([]int{0, 1})
+	if v := min.Value(); v != -2 {
+		t.Errorf("min above prior value, want: -2, got %d", v)
+	}
+}
+
 func TestPrefix(t *testing.T) {
 	box := NewCounterBox()
 	pref := box.WithPrefix("prefix:")
@@ -72,6 +337,797 @@ func TestPrefix(t *testing.T) {
 	}
 }
 
+func TestPairs(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("b").IncrementBy(2)
+	box.GetCounter("c").IncrementBy(3)
+	box.GetCounter("a").IncrementBy(1)
+	box.GetMin("z").Set(-4)
+	box.GetMin("y").Set(6)
+	box.GetMax("x").Set(9)
+
+	want := []CounterPair{{"a", 1}, {"b", 2}, {"c", 3}}
+	if got := box.Pairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Pairs, want: %v, got %v", want, got)
+	}
+	want = []CounterPair{{"y", 6}, {"z", -4}}
+	if got := box.MinPairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MinPairs, want: %v, got %v", want, got)
+	}
+	want = []CounterPair{{"x", 9}}
+	if got := box.MaxPairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MaxPairs, want: %v, got %v", want, got)
+	}
+}
+
+func TestAppendTo(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("test").Increment()
+	box.GetMax("max").Set(3)
+
+	prefix := []byte("dump: ")
+	got := box.AppendTo(prefix)
+	if want := "dump: " + box.String(); string(got) != want {
+		t.Errorf("AppendTo, want: %q, got %q", want, got)
+	}
+}
+
+func TestIncrementIfBelow(t *testing.T) {
+	const limit = 5
+	tests := []struct {
+		name string
+		box  *CounterBox
+		get  func(box *CounterBox, name string) Counter
+	}{
+		{"plain", NewCounterBox(), (*CounterBox).GetCounter},
+		{"sharded", NewCounterBox(), (*CounterBox).GetShardedCounter},
+		{"single threaded", NewCounterBox(WithUnsafeSingleThreaded()), (*CounterBox).GetCounter},
+	}
+	for _, tt := range tests {
+		box := tt.box
+		cnt := tt.get(box, "slots")
+		goroutines := 50
+		if tt.name == "single threaded" {
+			goroutines = 1
+		}
+		var accepted int64
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 20; i++ {
+					v, ok := box.IncrementIfBelow("slots", limit)
+					if v > limit {
+						t.Errorf("%s, want at most %d, got %d", tt.name, limit, v)
+					}
+					if ok {
+						atomic.AddInt64(&accepted, 1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		if accepted != limit {
+			t.Errorf("%s, want %d accepted, got %d", tt.name, limit, accepted)
+		}
+		if v := cnt.Value(); v != limit {
+			t.Errorf("%s, want: %d, got %d", tt.name, limit, v)
+		}
+
+		cnt.Decrement()
+		if v, ok := box.IncrementIfBelow("slots", limit); v != limit || !ok {
+			t.Errorf("%s, after decrement, want: %d true, got %d %t", tt.name, limit, v, ok)
+		}
+	}
+
+	box := NewCounterBox()
+	box.RegisterComputed("computed", func(*CounterBox) int64 { return 1 })
+	if v, ok := box.IncrementIfBelow("computed", limit); v != 1 || ok {
+		t.Errorf("computed, want: 1 false, got %d %t", v, ok)
+	}
+}
+
+func TestTryPeekCounter(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("test").IncrementBy(3)
+	if v, ok, acquired := box.TryPeekCounter("test"); v != 3 || !ok || !acquired {
+		t.Errorf("want: 3 true true, got %d %t %t", v, ok, acquired)
+	}
+	if v, ok, acquired := box.TryPeekCounter("missing"); v != 0 || ok || !acquired {
+		t.Errorf("want: 0 false true, got %d %t %t", v, ok, acquired)
+	}
+
+	locked, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		box.mu.Lock()
+		close(locked)
+		<-release
+		box.mu.Unlock()
+	}()
+	<-locked
+	if v, ok, acquired := box.TryPeekCounter("test"); v != 0 || ok || acquired {
+		t.Errorf("locked, want: 0 false false, got %d %t %t", v, ok, acquired)
+	}
+	close(release)
+}
+
+func TestInfo(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock), WithUpdateTimes())
+	created := clock.Now()
+	box.GetCounter("test")
+
+	info, ok := box.Info("test")
+	if !ok {
+		t.Fatalf("Info should find counter test")
+	}
+	if !info.CreatedAt.Equal(created) || !info.UpdatedAt.Equal(created) {
+		t.Errorf("want created and updated at %v, got %v and %v", created, info.CreatedAt, info.UpdatedAt)
+	}
+
+	clock.Advance(time.Minute)
+	box.GetCounter("test").Increment()
+	info, _ = box.Info("test")
+	if !info.CreatedAt.Equal(created) {
+		t.Errorf("created, want: %v, got %v", created, info.CreatedAt)
+	}
+	if want := created.Add(time.Minute); !info.UpdatedAt.Equal(want) {
+		t.Errorf("updated, want: %v, got %v", want, info.UpdatedAt)
+	}
+	if info.Value != 1 {
+		t.Errorf("value, want: 1, got %d", info.Value)
+	}
+
+	if _, ok := box.Info("missing"); ok {
+		t.Errorf("Info should not find counter missing")
+	}
+	if info.Source != "" {
+		t.Errorf("want no source without WithCreationTrace, got %q", info.Source)
+	}
+}
+
+func TestInfoWithoutUpdateTimes(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	created := clock.Now()
+	box.GetCounter("test")
+	clock.Advance(time.Minute)
+	box.GetCounter("test").Increment()
+	info, _ := box.Info("test")
+	if !info.CreatedAt.Equal(created) || !info.UpdatedAt.Equal(created) {
+		t.Errorf("want created and updated at %v, got %v and %v", created, info.CreatedAt, info.UpdatedAt)
+	}
+}
+
+func TestCreatedSince(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	box.GetCounter("old.b")
+	box.GetCounter("old.a")
+	deploy := clock.Now()
+	clock.Advance(time.Minute)
+	box.GetCounter("new.b")
+	box.GetCounter("new.a")
+	box.GetCounter("old.a").Increment()
+
+	if got, want := box.CreatedSince(deploy), []string{"new.a", "new.b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+	if got := box.CreatedSince(clock.Now()); got != nil {
+		t.Errorf("want none, got %v", got)
+	}
+}
+
+func TestInfoCreationTrace(t *testing.T) {
+	box := NewCounterBox(WithCreationTrace())
+	_, file, line, _ := runtime.Caller(0)
+	box.GetCounter("test")
+	box.GetCounter("test").Increment()
+
+	info, _ := box.Info("test")
+	if want := fmt.Sprintf("%s:%d", file, line+1); info.Source != want {
+		t.Errorf("want: %s, got %s", want, info.Source)
+	}
+	if !strings.HasSuffix(file, "counter_test.go") {
+		t.Errorf("want the test file, got %s", file)
+	}
+
+	// Counters created by helpers get the code calling the helper.
+	_, file, line, _ = runtime.Caller(0)
+	box.Count("requests", "method", "GET")
+	box.DeclareCounter("declared")
+	box.Group("group").GetCounter("grouped")
+	for i, name := range []string{`requests{method="GET"}`, "declared", "grouped"} {
+		info, _ := box.Info(name)
+		if want := fmt.Sprintf("%s:%d", file, line+1+i); info.Source != want {
+			t.Errorf("%s, want: %s, got %s", name, want, info.Source)
+		}
+	}
+}
+
+func TestValues(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("a").IncrementBy(1)
+	box.GetCounter("b").IncrementBy(2)
+
+	want := map[string]int64{"a": 1, "b": 2}
+	if got := box.Values("a", "b", "missing"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Values, want: %v, got %v", want, got)
+	}
+	if _, ok := box.Info("missing"); ok {
+		t.Errorf("Values should not create missing counters")
+	}
+}
+
+func TestValuesConsistent(t *testing.T) {
+	box := NewCounterBox()
+	a, b := box.GetCounter("a"), box.GetCounter("b")
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 1000; i++ {
+			box.mu.Lock()
+			a.Increment()
+			b.Increment()
+			box.mu.Unlock()
+		}
+		done <- true
+	}()
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		if v := box.Values("a", "b"); v["a"] != v["b"] {
+			t.Fatalf("inconsistent read: %v", v)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("b").IncrementBy(2)
+	box.GetCounter("a").IncrementBy(1)
+
+	var got []CounterPair
+	for p := range box.Stream(context.Background()) {
+		got = append(got, p)
+	}
+	if want := box.Pairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	box := NewCounterBox()
+	for i := 0; i < 1000; i++ {
+		box.GetCounter(fmt.Sprintf("c%d", i)).Increment()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := box.Stream(ctx)
+	<-ch
+	cancel()
+	n := 1
+	for range ch {
+		n++
+	}
+	if n == 1000 {
+		t.Errorf("stream should stop after cancel")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("a").IncrementBy(1)
+	box.GetCounter("b").IncrementBy(2)
+	box.GetCounter("c").IncrementBy(2)
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"a", "b", -1},
+		{"b", "a", 1},
+		{"b", "c", 0},
+		{"missing", "a", -1},
+	} {
+		if got := box.Compare(tc.a, tc.b); got != tc.want {
+			t.Errorf("Compare(%s, %s), want: %d, got %d", tc.a, tc.b, tc.want, got)
+		}
+	}
+}
+
+func TestCompareConcurrent(t *testing.T) {
+	box := NewCounterBox()
+	produced, consumed := box.GetCounter("produced"), box.GetCounter("consumed")
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 1000; i++ {
+			box.mu.Lock()
+			produced.Increment()
+			consumed.Increment()
+			box.mu.Unlock()
+		}
+		done <- true
+	}()
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		if v := box.Compare("produced", "consumed"); v != 0 {
+			t.Fatalf("inconsistent compare: %d", v)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	box := NewCounterBox()
+	cappedIncrement := func(old int64) int64 {
+		if old >= 500 {
+			return old
+		}
+		return old + 1
+	}
+	end := make(chan bool, 10)
+	for x := 0; x < 10; x++ {
+		go func() {
+			for y := 0; y < 100; y++ {
+				box.Update("test", cappedIncrement)
+			}
+			end <- true
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		<-end
+	}
+	if v := box.GetCounter("test").Value(); v != 500 {
+		t.Errorf("got %d, expected 500", v)
+	}
+	if v := box.Update("test", func(old int64) int64 { return old * 2 }); v != 1000 {
+		t.Errorf("got %d, expected 1000", v)
+	}
+}
+
+// fakeLogger keeps all logged messages.
+type fakeLogger struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (f *fakeLogger) Print(v ...interface{}) {
+	f.mu.Lock()
+	f.logs = append(f.logs, fmt.Sprint(v...))
+	f.mu.Unlock()
+}
+
+func (f *fakeLogger) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.logs)
+}
+
+func TestLogCountersEveryOnChange(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	box.GetCounter("test").Increment()
+	logger := &fakeLogger{}
+	LogCountersEveryOnChange(logger, box, time.Second)
+
+	// Every tick is received after the previous one was handled.
+	for i := 0; i < 4; i++ {
+		clock.Advance(time.Second)
+	}
+	if n := logger.count(); n != 0 {
+		t.Errorf("want no logs without changes, got %d", n)
+	}
+	box.GetCounter("test").Increment()
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Second)
+	}
+	if n := logger.count(); n != 1 {
+		t.Errorf("want one log after a change, got %d", n)
+	}
+}
+
+func TestDumpOnPanic(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("test").Increment()
+	logger := &fakeLogger{}
+	fn := func() {
+		defer box.DumpOnPanic(logger)
+		panic("boom")
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("want the original panic, got %v", r)
+			}
+		}()
+		fn()
+	}()
+	if len(logger.logs) != 1 || logger.logs[0] != box.String() {
+		t.Errorf("want the box dumped once, got %q", logger.logs)
+	}
+
+	func() {
+		defer box.DumpOnPanic(logger)
+	}()
+	if n := logger.count(); n != 1 {
+		t.Errorf("nothing should be logged without a panic, got %d logs", n)
+	}
+}
+
+func TestStrictNames(t *testing.T) {
+	tests := []struct {
+		name string
+		get  func(c *CounterBox)
+	}{
+		{"max after counter", func(c *CounterBox) { c.GetCounter("x"); c.GetMax("x") }},
+		{"counter after min", func(c *CounterBox) { c.GetMin("x"); c.GetCounter("x") }},
+		{"sharded after max", func(c *CounterBox) { c.GetMax("x"); c.GetShardedCounter("x") }},
+		{"computed after min", func(c *CounterBox) {
+			c.GetMin("x")
+			c.RegisterComputed("x", func(*CounterBox) int64 { return 0 })
+		}},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: want panic", tt.name)
+				}
+			}()
+			tt.get(NewCounterBox(WithStrictNames()))
+		}()
+	}
+}
+
+func TestStrictNamesAllowed(t *testing.T) {
+	box := NewCounterBox(WithStrictNames())
+	box.GetCounter("x").Increment()
+	box.GetCounter("x").Increment()
+	box.GetMin("y").Set(1)
+	box.GetMax("y").Set(2)
+	box.Time("op", func() {})
+	if v := box.GetCounter("x").Value(); v != 2 {
+		t.Errorf("want: 2, got %d", v)
+	}
+}
+
+func TestLenientNames(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("x").IncrementBy(3)
+	box.GetMin("x").Set(1)
+	box.GetMax("x").Set(2)
+	want := map[string]int64{"x": 3}
+	if got := box.Snapshot().Counters; !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+	if v := box.GetMax("x").Value(); v != 2 {
+		t.Errorf("max, want: 2, got %d", v)
+	}
+}
+
+func TestLogCountersDeltaEvery(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	box.GetCounter("a").IncrementBy(5)
+	logger := &fakeLogger{}
+	LogCountersDeltaEvery(logger, box, time.Second)
+
+	clock.Advance(time.Second)
+	waitFor(t, func() bool { return logger.count() == 1 })
+	box.GetCounter("a").IncrementBy(3)
+	box.GetCounter("b").Decrement()
+	clock.Advance(time.Second)
+	waitFor(t, func() bool { return logger.count() == 2 })
+
+	want := []string{
+		"== Counter deltas ==\n  a: +5 (5)",
+		"== Counter deltas ==\n  a: +3 (8)\n  b: -1 (-1)",
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if !reflect.DeepEqual(logger.logs, want) {
+		t.Errorf("want: %q, got %q", want, logger.logs)
+	}
+}
+
+func TestLogOnSpike(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	logger := &fakeLogger{}
+	stop := box.LogOnSpike(logger, "requests", 50, 5*time.Second)
+	defer stop()
+
+	// A tick may already see increments done for the next one, so the log
+	// after the cooldown comes at the 6th or the 7th second.
+	requests := box.GetCounter("requests")
+	for i := 1; i <= 10; i++ {
+		requests.IncrementBy(100)
+		clock.Advance(time.Second)
+		if i == 1 {
+			waitFor(t, func() bool { return logger.count() == 1 })
+		}
+		if n := logger.count(); i == 5 && n != 1 {
+			t.Errorf("want one log within the cooldown, got %d", n)
+		}
+	}
+	waitFor(t, func() bool { return logger.count() == 2 })
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if want := "counter requests spikes at "; !strings.HasPrefix(logger.logs[0], want) {
+		t.Errorf("want prefix: %q, got %q", want, logger.logs[0])
+	}
+}
+
+func TestWriteToOutputPrefix(t *testing.T) {
+	box := NewCounterBox(WithOutputPrefix("svc."))
+	box.GetCounter("requests").IncrementBy(3)
+	box.GetMin("latency").Set(2)
+	box.GetMax("latency").Set(5)
+	box.GetRatio("errors").Failure()
+	want := `== Counters ==
+  svc.requests: 3
+== Min values ==
+  svc.latency: 2
+== Max values ==
+  svc.latency: 5
+== Ratios ==
+  svc.errors: 1.0000`
+	if s := box.String(); s != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, s)
+	}
+	if _, ok := box.PeekCounter("svc.requests"); ok {
+		t.Error("prefixed name should not be a counter")
+	}
+	if v, _ := box.PeekCounter("requests"); v != 3 {
+		t.Errorf("want: 3, got %d", v)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("pending").IncrementBy(1000)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if (i+j)%2 == 0 {
+					box.Transfer("pending", "completed", int64(j%7))
+				} else {
+					box.Transfer("completed", "pending", int64(j%5))
+				}
+			}
+		}(i)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for k := 0; k < 1000; k++ {
+			v := box.Values("pending", "completed")
+			if sum := v["pending"] + v["completed"]; sum != 1000 {
+				t.Errorf("want total 1000, got %d", sum)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	<-done
+	v := box.Values("pending", "completed")
+	if sum := v["pending"] + v["completed"]; sum != 1000 {
+		t.Errorf("want total 1000, got %d", sum)
+	}
+}
+
+func TestWriteToMaxOutputBytes(t *testing.T) {
+	box := NewCounterBox(WithMaxOutputBytes(40))
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		box.GetCounter(name).Increment()
+	}
+	full := NewCounterBox()
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		full.GetCounter(name).Increment()
+	}
+	// The line of counter d ends after 42 bytes.
+	kept := "== Counters ==\n  a: 1\n  b: 1\n  c: 1"
+	more := len(full.String()) - len(kept)
+	want := fmt.Sprintf("%s\n... (truncated, %d more bytes)", kept, more)
+	buf := &strings.Builder{}
+	n, err := box.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+	if n != int64(len(want)) {
+		t.Errorf("want %d bytes, got %d", len(want), n)
+	}
+
+	box = NewCounterBox(WithMaxOutputBytes(1000))
+	box.GetCounter("a").Increment()
+	if s := box.String(); strings.Contains(s, "truncated") {
+		t.Errorf("want a short output untouched, got:\n%s", s)
+	}
+}
+
+func TestWriteToLimited(t *testing.T) {
+	box := NewCounterBox()
+	for _, name := range []string{"e", "d", "c", "b", "a"} {
+		box.GetCounter(name).Increment()
+	}
+	box.GetMin("x").Set(1)
+	box.GetMax("x").Set(1)
+	box.GetMax("y").Set(2)
+	box.GetMax("z").Set(3)
+	want := `== Counters ==
+  a: 1
+  b: 1
+  ... (3 more)
+== Min values ==
+  x: 1
+== Max values ==
+  x: 1
+  y: 2
+  ... (1 more)`
+	buf := &strings.Builder{}
+	n, err := box.WriteToLimited(buf, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+	if n != int64(len(want)) {
+		t.Errorf("want %d bytes, got %d", len(want), n)
+	}
+
+	buf.Reset()
+	box.WriteToLimited(buf, 10)
+	if got := buf.String(); got != box.String() {
+		t.Errorf("want the full output under the limit, got:\n%s", got)
+	}
+}
+
+func TestWriteToAlignedOutput(t *testing.T) {
+	box := NewCounterBox(WithAlignedOutput(), WithLabel("box"))
+	box.GetCounter("a").Increment()
+	box.GetCounter("requests.total").IncrementBy(1234)
+	box.GetMin("latency").Set(5)
+	box.GetMax("latency").Set(120)
+	want := `=== box ===
+== Counters ==
+  a:                 1
+  requests.total: 1234
+== Min values ==
+  latency:           5
+== Max values ==
+  latency:         120`
+	if got := box.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestInitCounters(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("existing").IncrementBy(7)
+	box.InitCounters(map[string]int64{"a": 1, "b": 20, "existing": 100})
+	want := []CounterPair{{"a", 1}, {"b", 20}, {"existing", 7}}
+	if got := box.Pairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+
+	box.GetCounter("a").Increment()
+	box.InitCounters(map[string]int64{"a": 1})
+	if v := box.GetCounter("a").Value(); v != 2 {
+		t.Errorf("want a not overwritten, want: 2, got %d", v)
+	}
+}
+
+func TestGrandTotal(t *testing.T) {
+	box := NewCounterBox()
+	if v := box.GrandTotal(); v != 0 {
+		t.Errorf("empty, want: 0, got %d", v)
+	}
+	box.GetCounter("http.get").IncrementBy(5)
+	box.GetCounter("http.post").IncrementBy(3)
+	box.GetShardedCounter("db.queries").IncrementBy(10)
+	box.RegisterComputed("computed", func(*CounterBox) int64 { return 1000 })
+	box.GetMax("http.get").Set(100)
+	box.GetMin("http.get").Set(100)
+
+	if v := box.GrandTotal(); v != 18 {
+		t.Errorf("total, want: 18, got %d", v)
+	}
+	if v := box.GrandTotalByPrefix("http."); v != 8 {
+		t.Errorf("http., want: 8, got %d", v)
+	}
+	if v := box.GrandTotalByPrefix("missing"); v != 0 {
+		t.Errorf("missing, want: 0, got %d", v)
+	}
+}
+
+func TestGrandTotalConcurrent(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("a").IncrementBy(500)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				box.Transfer("a", "b", 1)
+				box.Transfer("b", "a", 1)
+			}
+		}()
+	}
+	for j := 0; j < 1000; j++ {
+		if v := box.GrandTotal(); v != 500 {
+			t.Fatalf("want: 500, got %d", v)
+		}
+	}
+	wg.Wait()
+}
+
+func TestBusiestQuietest(t *testing.T) {
+	box := NewCounterBox()
+	if _, _, ok := box.Busiest(); ok {
+		t.Error("Busiest of an empty box should not be ok")
+	}
+	if _, _, ok := box.Quietest(); ok {
+		t.Error("Quietest of an empty box should not be ok")
+	}
+	box.GetCounter("/b").IncrementBy(7)
+	box.GetCounter("/c").IncrementBy(7)
+	box.GetCounter("/a").IncrementBy(3)
+	box.GetCounter("/e").IncrementBy(1)
+	box.GetCounter("/d").IncrementBy(1)
+	box.GetMax("/max").Set(100)
+
+	if name, v, ok := box.Busiest(); name != "/b" || v != 7 || !ok {
+		t.Errorf("Busiest, want: /b 7 true, got %s %d %v", name, v, ok)
+	}
+	if name, v, ok := box.Quietest(); name != "/d" || v != 1 || !ok {
+		t.Errorf("Quietest, want: /d 1 true, got %s %d %v", name, v, ok)
+	}
+}
+
+func TestOnTick(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	var mu sync.Mutex
+	calls := 0
+	stop := box.OnTick(time.Minute, func(b *CounterBox) {
+		if b != box {
+			t.Errorf("want the box passed to fn")
+		}
+		mu.Lock()
+		calls++
+		mu.Unlock()
+	})
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+	for i := 1; i <= 3; i++ {
+		clock.Advance(time.Minute)
+		waitFor(t, func() bool { return count() == i })
+	}
+	stop()
+	stop()
+	clock.Advance(time.Minute)
+	if n := count(); n != 3 {
+		t.Errorf("want 3 calls after stop, got %d", n)
+	}
+}
+
 func BenchmarkCounters(b *testing.B) {
 	b.StopTimer()
 	e := make(chan bool)
@@ -134,3 +1190,55 @@ func BenchmarkCountersCached(b *testing.B) {
 	<-e
 	<-e
 }
+
+func newBenchmarkBox() *CounterBox {
+	c := NewCounterBox()
+	for i := 0; i < 20; i++ {
+		c.GetCounter(fmt.Sprintf("counter%d", i)).IncrementBy(i)
+		c.GetMax(fmt.Sprintf("max%d", i)).Set(i)
+	}
+	return c
+}
+
+func BenchmarkString(b *testing.B) {
+	c := newBenchmarkBox()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.String()
+	}
+}
+
+func BenchmarkAppendTo(b *testing.B) {
+	c := newBenchmarkBox()
+	var buf []byte
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf = c.AppendTo(buf[:0])
+	}
+}
+
+func newLargeBox() *CounterBox {
+	c := NewCounterBox()
+	for i := 0; i < 5000; i++ {
+		c.GetCounter(fmt.Sprintf("counter%d", i)).IncrementBy(i)
+	}
+	return c
+}
+
+func BenchmarkWriteTo(b *testing.B) {
+	c := newLargeBox()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.WriteTo(io.Discard)
+	}
+}
+
+func BenchmarkWriteToUnsorted(b *testing.B) {
+	c := newLargeBox()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.WriteToUnsorted(io.Discard)
+	}
+}
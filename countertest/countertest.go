@@ -0,0 +1,40 @@
+// Package countertest provides helpers for testing code which updates
+// counters.CounterBox counters.
+package countertest
+
+import (
+	"testing"
+
+	"github.com/orian/counters"
+)
+
+// AssertCounter reports an error if counter name of box doesn't have value
+// want. A counter which doesn't exist has value 0, it's not created.
+func AssertCounter(t testing.TB, box *counters.CounterBox, name string, want int64) {
+	t.Helper()
+	v, ok := box.PeekCounter(name)
+	switch {
+	case v == want:
+	case !ok:
+		t.Errorf("counter %q doesn't exist, want %d", name, want)
+	default:
+		t.Errorf("counter %q is %d, want %d", name, v, want)
+	}
+}
+
+// AssertDelta runs fn and reports an error if it didn't change counter name
+// of box by want. A counter which doesn't exist has value 0, it's not
+// created.
+func AssertDelta(t testing.TB, box *counters.CounterBox, name string, want int64, fn func()) {
+	t.Helper()
+	before, _ := box.PeekCounter(name)
+	fn()
+	after, ok := box.PeekCounter(name)
+	switch {
+	case after-before == want:
+	case !ok:
+		t.Errorf("counter %q doesn't exist, want change by %d", name, want)
+	default:
+		t.Errorf("counter %q changed by %d from %d to %d, want change by %d", name, after-before, before, after, want)
+	}
+}
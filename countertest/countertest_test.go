@@ -0,0 +1,67 @@
+package countertest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/orian/counters"
+)
+
+// fakeTB records errors instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertCounter(t *testing.T) {
+	box := counters.NewCounterBox()
+	box.GetCounter("requests").IncrementBy(3)
+	tests := []struct {
+		name   string
+		want   int64
+		errors []string
+	}{
+		{"requests", 3, nil},
+		{"missing", 0, nil},
+		{"requests", 4, []string{`counter "requests" is 3, want 4`}},
+		{"missing", 1, []string{`counter "missing" doesn't exist, want 1`}},
+	}
+	for _, tt := range tests {
+		tb := &fakeTB{}
+		AssertCounter(tb, box, tt.name, tt.want)
+		if fmt.Sprint(tb.errors) != fmt.Sprint(tt.errors) {
+			t.Errorf("%s %d, want: %q, got %q", tt.name, tt.want, tt.errors, tb.errors)
+		}
+	}
+	if _, ok := box.PeekCounter("missing"); ok {
+		t.Error("AssertCounter should not create counters")
+	}
+}
+
+func TestAssertDelta(t *testing.T) {
+	box := counters.NewCounterBox()
+	box.GetCounter("requests").IncrementBy(3)
+
+	tb := &fakeTB{}
+	AssertDelta(tb, box, "requests", 2, func() { box.GetCounter("requests").IncrementBy(2) })
+	AssertDelta(tb, box, "missing", 0, func() {})
+	if len(tb.errors) != 0 {
+		t.Errorf("want no errors, got %q", tb.errors)
+	}
+
+	AssertDelta(tb, box, "requests", 1, func() { box.GetCounter("requests").IncrementBy(5) })
+	AssertDelta(tb, box, "missing", 1, func() {})
+	want := []string{
+		`counter "requests" changed by 5 from 5 to 10, want change by 1`,
+		`counter "missing" doesn't exist, want change by 1`,
+	}
+	if fmt.Sprint(tb.errors) != fmt.Sprint(want) {
+		t.Errorf("want: %q, got %q", want, tb.errors)
+	}
+}
@@ -0,0 +1,73 @@
+package counters
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// DecayingCounter is a counter which value decays exponentially over time,
+// it halves every half-life.
+type DecayingCounter interface {
+	// Add adds v to the current value.
+	Add(v float64)
+	// Name returns a name of counter.
+	Name() string
+	// Value returns the current value.
+	Value() float64
+}
+
+type decayingCounter struct {
+	name     string
+	halfLife time.Duration
+	clock    Clock
+
+	mu    sync.Mutex
+	value float64
+	last  time.Time
+}
+
+// decay updates the value to the current time, mu must be held.
+func (d *decayingCounter) decay() {
+	now := d.clock.Now()
+	if elapsed := now.Sub(d.last); elapsed > 0 {
+		d.value *= math.Exp2(-float64(elapsed) / float64(d.halfLife))
+	}
+	d.last = now
+}
+
+func (d *decayingCounter) Add(v float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.decay()
+	d.value += v
+}
+
+func (d *decayingCounter) Name() string {
+	return d.name
+}
+
+func (d *decayingCounter) Value() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.decay()
+	return d.value
+}
+
+// GetDecayingCounter returns a decaying counter of given name, if doesn't
+// exist than create it with given half-life. The time is measured with the
+// box clock.
+func (c *CounterBox) GetDecayingCounter(name string, halfLife time.Duration) DecayingCounter {
+	name = c.checkName(name)
+	value, ok := c.decaying.Load(name)
+	if !ok {
+		value, _ = c.decaying.LoadOrStore(name, &decayingCounter{
+			name:     name,
+			halfLife: halfLife,
+			clock:    c.clock,
+			last:     c.clock.Now(),
+		})
+	}
+	v, _ := value.(DecayingCounter)
+	return v
+}
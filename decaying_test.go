@@ -0,0 +1,28 @@
+package counters
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDecayingCounter(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	cnt := box.GetDecayingCounter("activity", time.Minute)
+	cnt.Add(100)
+	if v := cnt.Value(); v != 100 {
+		t.Errorf("want: 100, got %f", v)
+	}
+
+	clock.Advance(time.Minute)
+	if v := cnt.Value(); math.Abs(v-50) > 1e-9 {
+		t.Errorf("after half-life, want: 50, got %f", v)
+	}
+
+	cnt.Add(50)
+	clock.Advance(2 * time.Minute)
+	if v := box.GetDecayingCounter("activity", time.Hour).Value(); math.Abs(v-25) > 1e-9 {
+		t.Errorf("after two half-lives, want: 25, got %f", v)
+	}
+}
@@ -0,0 +1,154 @@
+package counters
+
+import (
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DogStatsDErrors is a name of a counter of failed sends to DogStatsD.
+const DogStatsDErrors = "counters.dogstatsd.errors"
+
+// maxDatagram is a size of a datagram which fits into a typical MTU.
+const maxDatagram = 1432
+
+var dogStatsDReplacer = strings.NewReplacer(":", "_", "|", "_", "@", "_", ",", "_", "#", "_", "\n", "_")
+
+// StartDogStatsD sends values of the box to a DogStatsD agent at addr
+// over UDP every d. Counters are sent as a change since the previous send
+// with type c, unchanged counters are skipped. Max and min values are sent
+// as gauges name.max and name.min, min values which were never set are
+// skipped. Names are prefixed with prefix and a dot if prefix isn't empty.
+// Every metric has tags, e.g. "env:prod", and counters created by Count
+// get their labels as additional tags. A failed send increments counter
+// DogStatsDErrors and the next send continues from the values not sent yet.
+// A counter reset since it was sent, see ResetEpoch, is sent with its whole
+// value.
+// The sender is registered as a pusher, see Flush. It returns a function
+// which stops sending.
+func (c *CounterBox) StartDogStatsD(addr, prefix string, tags []string, d time.Duration) (stop func(), err error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != "" {
+		prefix += "."
+	}
+	sent := map[string]dogStatsDSent{}
+	push := PusherFunc(func() error {
+		return c.sendDogStatsD(conn, prefix, tags, sent)
+	})
+	return c.startPusher(push, d, func() { conn.Close() }), nil
+}
+
+// dogStatsDSent is a value of a counter sent to DogStatsD and its reset
+// epoch at the time.
+type dogStatsDSent struct {
+	value, epoch int64
+}
+
+// sendDogStatsD writes the box to w as datagrams. sent holds the counter
+// values already sent, it's updated after every written datagram, so
+// a failed write doesn't resend the datagrams written before it.
+func (c *CounterBox) sendDogStatsD(w io.Writer, prefix string, tags []string, sent map[string]dogStatsDSent) error {
+	for _, packet := range c.dogStatsDPackets(prefix, tags, c.Snapshot(), sent) {
+		if _, err := w.Write(packet.data); err != nil {
+			c.GetCounter(DogStatsDErrors).Increment()
+			return err
+		}
+		for name, v := range packet.counters {
+			sent[name] = v
+		}
+	}
+	return nil
+}
+
+// dogStatsDPacket is a datagram and the counters sent in it.
+type dogStatsDPacket struct {
+	data     []byte
+	counters map[string]dogStatsDSent
+}
+
+// dogStatsDPackets returns lines describing s grouped into datagrams.
+func (c *CounterBox) dogStatsDPackets(prefix string, tags []string, s CounterSnapshot, sent map[string]dogStatsDSent) []dogStatsDPacket {
+	var packets []dogStatsDPacket
+	var packet dogStatsDPacket
+	add := func(name string, v int64, kind string) {
+		line := c.appendDogStatsD(nil, prefix, name, v, kind, tags)
+		if len(packet.data) > 0 && len(packet.data)+1+len(line) > maxDatagram {
+			packets = append(packets, packet)
+			packet = dogStatsDPacket{}
+		}
+		if len(packet.data) > 0 {
+			packet.data = append(packet.data, '\n')
+		}
+		packet.data = append(packet.data, line...)
+	}
+	for _, name := range sortedNames(s.Counters) {
+		cur := dogStatsDSent{s.Counters[name], c.ResetEpoch(name)}
+		delta := cur.value - sent[name].value
+		if cur.epoch != sent[name].epoch {
+			// The counter was reset, its value was counted since.
+			delta = cur.value
+		}
+		if delta != 0 {
+			add(name, delta, "c")
+			if packet.counters == nil {
+				packet.counters = make(map[string]dogStatsDSent)
+			}
+			packet.counters[name] = cur
+		}
+	}
+	for _, name := range sortedNames(s.Max) {
+		add(name+".max", s.Max[name], "g")
+	}
+	for _, name := range sortedNames(s.Min) {
+		if v := s.Min[name]; v != math.MaxInt64 {
+			add(name+".min", v, "g")
+		}
+	}
+	if len(packet.data) > 0 {
+		packets = append(packets, packet)
+	}
+	return packets
+}
+
+// appendDogStatsD appends a line name:v|kind|#tags to b, the name of
+// a labeled counter is replaced with its base name and labels are added
+// to tags.
+func (c *CounterBox) appendDogStatsD(b []byte, prefix, name string, v int64, kind string, tags []string) []byte {
+	var labels []Label
+	if kind == "c" {
+		if l, ok := c.labels.Load(name); ok {
+			ln := l.(*labeledName)
+			name, labels = ln.base, ln.labels
+		}
+	}
+	b = append(b, dogStatsDReplacer.Replace(prefix+name)...)
+	b = append(b, ':')
+	b = strconv.AppendInt(b, v, 10)
+	b = append(b, '|')
+	b = append(b, kind...)
+	for i, tag := range tags {
+		if i == 0 {
+			b = append(b, "|#"...)
+		} else {
+			b = append(b, ',')
+		}
+		b = append(b, tag...)
+	}
+	for i, l := range labels {
+		if i == 0 && len(tags) == 0 {
+			b = append(b, "|#"...)
+		} else {
+			b = append(b, ',')
+		}
+		b = append(b, dogStatsDReplacer.Replace(l.Name)...)
+		b = append(b, ':')
+		b = append(b, dogStatsDReplacer.Replace(l.Value)...)
+	}
+	return b
+}
@@ -0,0 +1,156 @@
+package counters
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readPacket(t *testing.T, conn net.PacketConn) []string {
+	t.Helper()
+	buf := make([]byte, 2*maxDatagram)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(string(buf[:n]), "\n")
+}
+
+func TestStartDogStatsD(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	stop, err := box.StartDogStatsD(conn.LocalAddr().String(), "app", []string{"env:test"}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	box.GetCounter("requests").IncrementBy(3)
+	box.GetCounter("idle")
+	box.Count("http", "method", "GET")
+	box.GetMax("latency").Set(7)
+	box.DeclareMin("latency")
+	clock.Advance(time.Second)
+	want := []string{
+		`app.http:1|c|#env:test,method:GET`,
+		`app.requests:3|c|#env:test`,
+		`app.latency.max:7|g|#env:test`,
+	}
+	if got := readPacket(t, conn); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %q, got %q", want, got)
+	}
+
+	box.GetCounter("requests").IncrementBy(2)
+	box.GetMin("latency").Set(4)
+	clock.Advance(time.Second)
+	want = []string{
+		`app.requests:2|c|#env:test`,
+		`app.latency.max:7|g|#env:test`,
+		`app.latency.min:4|g|#env:test`,
+	}
+	if got := readPacket(t, conn); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %q, got %q", want, got)
+	}
+
+	// A reset counter is sent with its value since the reset.
+	box.ResetPrefix("requests")
+	box.GetCounter("requests").IncrementBy(1)
+	clock.Advance(time.Second)
+	want = []string{
+		`app.requests:1|c|#env:test`,
+		`app.latency.max:7|g|#env:test`,
+		`app.latency.min:4|g|#env:test`,
+	}
+	if got := readPacket(t, conn); !reflect.DeepEqual(got, want) {
+		t.Errorf("after reset, want: %q, got %q", want, got)
+	}
+}
+
+func TestDogStatsDPackets(t *testing.T) {
+	box := NewCounterBox()
+	s := CounterSnapshot{Counters: map[string]int64{}}
+	for i := 0; i < 200; i++ {
+		s.Counters[strings.Repeat("x", 20)+string(rune('a'+i%26))+strings.Repeat("y", i/26)] = 1
+	}
+	packets := box.dogStatsDPackets("", nil, s, map[string]dogStatsDSent{})
+	if len(packets) < 2 {
+		t.Errorf("want several packets, got %d", len(packets))
+	}
+	lines := 0
+	for _, p := range packets {
+		if len(p.data) > maxDatagram {
+			t.Errorf("want packets of at most %d bytes, got %d", maxDatagram, len(p.data))
+		}
+		lines += len(strings.Split(string(p.data), "\n"))
+	}
+	if lines != 200 {
+		t.Errorf("want: 200 lines, got %d", lines)
+	}
+	if got, want := string(box.appendDogStatsD(nil, "", "a:b|c", 1, "c", nil)), "a_b_c:1|c"; got != want {
+		t.Errorf("want: %q, got %q", want, got)
+	}
+}
+
+// packetWriter records written packets and fails after the first ok ones.
+type packetWriter struct {
+	ok      int
+	packets []string
+}
+
+func (w *packetWriter) Write(p []byte) (int, error) {
+	if len(w.packets) == w.ok {
+		return 0, errWrite
+	}
+	w.packets = append(w.packets, string(p))
+	return len(p), nil
+}
+
+func TestSendDogStatsDPartialFailure(t *testing.T) {
+	box := NewCounterBox()
+	for i := 0; i < 200; i++ {
+		box.GetCounter(strings.Repeat("x", 20) + string(rune('a'+i%26)) + strings.Repeat("y", i/26)).Increment()
+	}
+	sent := map[string]dogStatsDSent{}
+	w := &packetWriter{ok: 1}
+	if err := box.sendDogStatsD(w, "", nil, sent); err == nil {
+		t.Fatal("want an error for a failed write")
+	}
+	if got := box.GetCounter(DogStatsDErrors).Value(); got != 1 {
+		t.Errorf("want: 1 error, got %d", got)
+	}
+	first := len(strings.Split(w.packets[0], "\n"))
+	if len(sent) != first {
+		t.Errorf("want: %d counters sent, got %d", first, len(sent))
+	}
+
+	w.ok = 100
+	if err := box.sendDogStatsD(w, "", nil, sent); err != nil {
+		t.Fatal(err)
+	}
+	lines := 0
+	for _, p := range w.packets {
+		for _, line := range strings.Split(p, "\n") {
+			if !strings.HasPrefix(line, DogStatsDErrors) {
+				lines++
+			}
+		}
+	}
+	if lines != 200 {
+		t.Errorf("want: every counter sent once, got %d lines", lines)
+	}
+}
+
+func TestStartDogStatsDErrors(t *testing.T) {
+	if _, err := NewCounterBox().StartDogStatsD("invalid address", "", nil, time.Second); err == nil {
+		t.Error("want an error for an invalid address")
+	}
+}
@@ -0,0 +1,46 @@
+package counters
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// setDisabled sets the disabled flag of a counter kept in m under name,
+// it reports whether the counter exists.
+func setDisabled(m *sync.Map, name string, disabled int32) bool {
+	value, ok := m.Load(name)
+	if !ok {
+		return false
+	}
+	switch v := value.(type) {
+	case *counterImpl:
+		atomic.StoreInt32(&v.disabled, disabled)
+	case *minImpl:
+		atomic.StoreInt32(&v.disabled, disabled)
+	case *maxImpl:
+		atomic.StoreInt32(&v.disabled, disabled)
+	default:
+		return false
+	}
+	return true
+}
+
+// Disable makes the counter, the min and the max value of given name ignore
+// updates, e.g. Increment or Set do nothing. Their values are kept and still
+// printed, resets still work. Sharded and computed counters can't be
+// disabled. It reports whether any value was disabled.
+func (c *CounterBox) Disable(name string) bool {
+	name = c.checkName(name)
+	ok := setDisabled(c.counters, name, 1)
+	ok = setDisabled(c.min, name, 1) || ok
+	return setDisabled(c.max, name, 1) || ok
+}
+
+// Enable makes values disabled by Disable accept updates again. It reports
+// whether any value was enabled.
+func (c *CounterBox) Enable(name string) bool {
+	name = c.checkName(name)
+	ok := setDisabled(c.counters, name, 0)
+	ok = setDisabled(c.min, name, 0) || ok
+	return setDisabled(c.max, name, 0) || ok
+}
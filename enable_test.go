@@ -0,0 +1,56 @@
+package counters
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDisable(t *testing.T) {
+	box := NewCounterBox()
+	c := box.GetCounter("test")
+	c.IncrementBy(5)
+	box.GetMax("test").Set(3)
+	box.GetMin("test").Set(3)
+
+	if !box.Disable("test") {
+		t.Error("Disable should find counter test")
+	}
+	if v := c.Increment(); v != 5 {
+		t.Errorf("Increment of disabled, want: 5, got %d", v)
+	}
+	c.IncrementBy(10)
+	c.Decrement()
+	c.Set(100)
+	box.GetMax("test").Set(30)
+	box.GetMin("test").Set(1)
+	if v := c.Value(); v != 5 {
+		t.Errorf("counter, want: 5, got %d", v)
+	}
+	if v := box.GetMax("test").Value(); v != 3 {
+		t.Errorf("max, want: 3, got %d", v)
+	}
+	if v := box.GetMin("test").Value(); v != 3 {
+		t.Errorf("min, want: 3, got %d", v)
+	}
+	if s := box.String(); !strings.Contains(s, "test: 5") {
+		t.Errorf("want disabled counter printed, got:\n%s", s)
+	}
+
+	if !box.Enable("test") {
+		t.Error("Enable should find counter test")
+	}
+	if v := c.Increment(); v != 6 {
+		t.Errorf("Increment of enabled, want: 6, got %d", v)
+	}
+	box.GetMax("test").Set(30)
+	if v := box.GetMax("test").Value(); v != 30 {
+		t.Errorf("max, want: 30, got %d", v)
+	}
+
+	if box.Disable("missing") {
+		t.Error("Disable should not find counter missing")
+	}
+	if _, ok := box.PeekCounter("missing"); ok {
+		t.Error("Disable should not create counters")
+	}
+}
@@ -0,0 +1,31 @@
+package counters
+
+import "errors"
+
+// errorType is an error recognized by CountErrorByType.
+type errorType struct {
+	name   string
+	target error
+}
+
+// OtherErrors is a suffix of the counter of errors not set by
+// WithErrorTypes, see CountErrorByType.
+const OtherErrors = "other"
+
+// CountErrorByType increments counter name.<type> where type is a name of
+// the first error type set by WithErrorTypes which err matches, checked with
+// errors.Is, so wrapped errors are recognized. Errors of no type are counted
+// in name.other. A nil err isn't counted.
+func (c *CounterBox) CountErrorByType(name string, err error) {
+	if err == nil {
+		return
+	}
+	typ := OtherErrors
+	for _, t := range c.errorTypes {
+		if errors.Is(err, t.target) {
+			typ = t.name
+			break
+		}
+	}
+	c.GetCounter(name + "." + typ).Increment()
+}
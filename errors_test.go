@@ -0,0 +1,27 @@
+package counters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestCountErrorByType(t *testing.T) {
+	box := NewCounterBox(WithErrorTypes(map[string]error{
+		"timeout": context.DeadlineExceeded,
+		"eof":     io.EOF,
+	}))
+	box.CountErrorByType("db", context.DeadlineExceeded)
+	box.CountErrorByType("db", fmt.Errorf("query: %w", context.DeadlineExceeded))
+	box.CountErrorByType("db", fmt.Errorf("read: %w", fmt.Errorf("row: %w", io.EOF)))
+	box.CountErrorByType("db", errors.New("unknown"))
+	box.CountErrorByType("db", nil)
+
+	want := []CounterPair{{"db.eof", 1}, {"db.other", 1}, {"db.timeout", 2}}
+	if got := box.Pairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+}
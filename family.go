@@ -0,0 +1,128 @@
+package counters
+
+import "sort"
+
+// MetricFamily is a set of samples sharing a name, e.g. all counters of
+// a name created with Count, in a form compatible with Prometheus.
+type MetricFamily struct {
+	// Name is a Prometheus name of the family, with the prefix set by
+	// WithOutputPrefix and a _min or _max suffix for min and max values.
+	Name string
+	// Type is PrometheusCounter or PrometheusGauge.
+	Type string
+	Help string
+	// Samples are sorted by labels.
+	Samples []Sample
+}
+
+// Sample is a single value of a metric family.
+type Sample struct {
+	// Labels are given to Count, set by SetTags or WithConstLabels, sorted
+	// by name.
+	Labels []Label
+	Value  float64
+}
+
+// MetricFamilies returns values of all counters grouped into metric
+// families, which can be transformed to any format. Counters come first,
+// then min and max values and float min and max values, each group sorted
+// by family name. Computed counters are gauges, like min and max values.
+// Float minima which were never set are omitted.
+func (c *CounterBox) MetricFamilies() []MetricFamily {
+	return c.metricFamilies(PrometheusGauge, false)
+}
+
+// metricFamilies returns families of MetricFamilies, min and max values
+// get type minMaxType. With resets the counters are followed by families
+// of reset epochs of counters which were reset, see ResetEpoch.
+func (c *CounterBox) metricFamilies(minMaxType string, resets bool) []MetricFamily {
+	s := c.Snapshot()
+	var families []MetricFamily
+	families = c.appendFamilies(families, intValues(s.Counters), "", "", "Counter")
+	if resets {
+		epochs := make(map[string]float64)
+		for name := range s.Counters {
+			if e := c.ResetEpoch(name); e > 0 {
+				epochs[name] = float64(e)
+			}
+		}
+		families = c.appendFamilies(families, epochs, "_reset_total", PrometheusCounter, "Number of resets of")
+	}
+	families = c.appendFamilies(families, intValues(s.Min), "_min", minMaxType, "Min value of")
+	families = c.appendFamilies(families, intValues(s.Max), "_max", minMaxType, "Max value of")
+	families = c.appendFamilies(families, floatValues(c.FloatMinPairs()), "_min", minMaxType, "Min value of")
+	return c.appendFamilies(families, floatValues(c.FloatMaxPairs()), "_max", minMaxType, "Max value of")
+}
+
+// intValues converts values of a snapshot to samples values.
+func intValues(values map[string]int64) map[string]float64 {
+	m := make(map[string]float64, len(values))
+	for name, v := range values {
+		m[name] = float64(v)
+	}
+	return m
+}
+
+// floatValues returns values of pairs by their names.
+func floatValues(pairs []FloatPair) map[string]float64 {
+	m := make(map[string]float64, len(pairs))
+	for _, p := range pairs {
+		m[p.Name] = p.Value
+	}
+	return m
+}
+
+// appendFamilies appends families of values to families, typ is the type
+// of the families, if it's empty counters are counters or gauges, see
+// familyType.
+func (c *CounterBox) appendFamilies(families []MetricFamily, values map[string]float64, suffix, typ, help string) []MetricFamily {
+	start := len(families)
+	index := make(map[string]int)
+	for _, name := range sortedNames(values) {
+		base, labels := c.exportLabels(name)
+		fullName := prometheusName(c.outputPrefix+base) + suffix
+		i, ok := index[fullName]
+		if !ok {
+			i = len(families)
+			index[fullName] = i
+			families = append(families, MetricFamily{
+				Name: fullName,
+				Type: c.familyType(name, typ),
+				Help: help + " " + c.outputPrefix + base + ".",
+			})
+		}
+		families[i].Samples = append(families[i].Samples, Sample{labels, values[name]})
+	}
+	part := families[start:]
+	sort.SliceStable(part, func(i, j int) bool { return part[i].Name < part[j].Name })
+	return families
+}
+
+// exportLabels returns the base name of a value of name and its labels:
+// labels given to Count, tags and const labels, sorted by name.
+func (c *CounterBox) exportLabels(name string) (base string, labels []Label) {
+	base = name
+	if v, ok := c.labels.Load(name); ok {
+		l := v.(*labeledName)
+		base, labels = l.base, append(labels, l.labels...)
+	}
+	if tags := c.exportTags(name); len(tags) > 0 {
+		labels = append(labels, tags...)
+		sort.SliceStable(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	}
+	return base, labels
+}
+
+// familyType returns typ, or a Prometheus type of a counter of name if typ
+// is empty.
+func (c *CounterBox) familyType(name, typ string) string {
+	if typ != "" {
+		return typ
+	}
+	if v, ok := c.counters.Load(name); ok {
+		if m, ok := v.(Metric); ok && m.Kind() == KindGauge {
+			return PrometheusGauge
+		}
+	}
+	return PrometheusCounter
+}
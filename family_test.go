@@ -0,0 +1,47 @@
+package counters
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMetricFamilies(t *testing.T) {
+	box := NewCounterBox(WithOutputPrefix("app."))
+	box.GetCounter("b").IncrementBy(2)
+	box.GetCounter("a").Increment()
+	box.RegisterComputed("c", func(*CounterBox) int64 { return 5 })
+	box.SetTags("a", map[string]string{"env": "prod"})
+	box.GetMin("lat").Set(3)
+	box.GetMax("lat").Set(7)
+
+	want := []MetricFamily{
+		{"app_a", PrometheusCounter, "Counter app.a.", []Sample{{[]Label{{"env", "prod"}}, 1}}},
+		{"app_b", PrometheusCounter, "Counter app.b.", []Sample{{nil, 2}}},
+		{"app_c", PrometheusGauge, "Counter app.c.", []Sample{{nil, 5}}},
+		{"app_lat_min", PrometheusGauge, "Min value of app.lat.", []Sample{{nil, 3}}},
+		{"app_lat_max", PrometheusGauge, "Max value of app.lat.", []Sample{{nil, 7}}},
+	}
+	if got := box.MetricFamilies(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %+v, got %+v", want, got)
+	}
+}
+
+func TestMetricFamiliesLabeled(t *testing.T) {
+	box := NewCounterBox()
+	box.Count("requests", "method", "POST", "status", "500")
+	box.Count("requests", "status", "200", "method", "GET")
+	box.Count("requests", "method", "GET", "status", "200")
+	box.Count("requests_total")
+	box.SetTags(`requests{method="POST",status="500"}`, map[string]string{"host": "a"})
+
+	want := []MetricFamily{
+		{"requests", PrometheusCounter, "Counter requests.", []Sample{
+			{[]Label{{"method", "GET"}, {"status", "200"}}, 2},
+			{[]Label{{"host", "a"}, {"method", "POST"}, {"status", "500"}}, 1},
+		}},
+		{"requests_total", PrometheusCounter, "Counter requests_total.", []Sample{{nil, 1}}},
+	}
+	if got := box.MetricFamilies(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,44 @@
+package counters
+
+// FlagCounter is a counter which value can change only from 0 to 1,
+// it records whether something happened at least once.
+type FlagCounter interface {
+	// Trigger sets the value to 1, it returns true if this call changed it.
+	Trigger() (firstTime bool)
+	// Triggered returns whether the flag was set.
+	Triggered() bool
+	// Name returns a name of counter.
+	Name() string
+	// Value returns 1 if the flag was set and 0 otherwise.
+	Value() int64
+}
+
+type flagCounter struct {
+	Counter
+}
+
+func (f flagCounter) Trigger() (firstTime bool) {
+	u, ok := f.Counter.(updater)
+	if !ok {
+		// A computed counter can't be set.
+		return false
+	}
+	u.update(func(old int64) int64 {
+		firstTime = old == 0
+		if firstTime {
+			return 1
+		}
+		return old
+	})
+	return firstTime
+}
+
+func (f flagCounter) Triggered() bool {
+	return f.Counter.Value() != 0
+}
+
+// GetFlagCounter returns a flag counter of given name, it shares the value
+// with the counter returned by GetCounter.
+func (c *CounterBox) GetFlagCounter(name string) FlagCounter {
+	return flagCounter{c.GetCounter(name)}
+}
@@ -0,0 +1,33 @@
+package counters
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFlagCounter(t *testing.T) {
+	box := NewCounterBox()
+	var first int64
+	var wg sync.WaitGroup
+	for x := 0; x < 50; x++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if box.GetFlagCounter("seen").Trigger() {
+				atomic.AddInt64(&first, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if first != 1 {
+		t.Errorf("want exactly one first trigger, got %d", first)
+	}
+	flag := box.GetFlagCounter("seen")
+	if !flag.Triggered() || flag.Value() != 1 {
+		t.Errorf("flag should be triggered, got value %d", flag.Value())
+	}
+	if box.GetFlagCounter("other").Triggered() {
+		t.Errorf("new flag should not be triggered")
+	}
+}
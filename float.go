@@ -0,0 +1,203 @@
+package counters
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// FloatCounter is a counter of float values.
+type FloatCounter interface {
+	// Add adds v to the counter.
+	Add(v float64) float64
+	// Set sets a specific value.
+	Set(v float64)
+	// Name returns a name of counter.
+	Name() string
+	// Value returns a current value of counter.
+	Value() float64
+}
+
+// floatCounter keeps bits of a float64 value in an uint64.
+type floatCounter struct {
+	name string
+	bits uint64
+	// scale and decimals are applied when the value is printed.
+	scale    float64
+	decimals int
+}
+
+func (f *floatCounter) Add(v float64) float64 {
+	for {
+		o := atomic.LoadUint64(&f.bits)
+		n := math.Float64frombits(o) + v
+		if atomic.CompareAndSwapUint64(&f.bits, o, math.Float64bits(n)) {
+			return n
+		}
+	}
+}
+
+func (f *floatCounter) Set(v float64) {
+	atomic.StoreUint64(&f.bits, math.Float64bits(v))
+}
+
+func (f *floatCounter) Name() string {
+	return f.name
+}
+
+func (f *floatCounter) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&f.bits))
+}
+
+// text returns the value formatted for printing.
+func (f *floatCounter) text() string {
+	return strconv.FormatFloat(f.Value()*f.scale, 'f', f.decimals, 64)
+}
+
+// GetFloatCounter returns a float counter of given name, if doesn't exist
+// than create. Float counters are printed by WriteTo in a separate section.
+func (c *CounterBox) GetFloatCounter(name string) FloatCounter {
+	return c.GetFloatCounterScaled(name, 1, -1)
+}
+
+// GetFloatCounterScaled works like GetFloatCounter, additionally the value
+// is multiplied by scale and rounded to decimals digits when it's printed,
+// e.g. a scale 1e-6 turns nanoseconds into milliseconds. A negative decimals
+// prints as many digits as needed. The counter keeps the full value. If the
+// counter already exists, its original scale and decimals are kept.
+func (c *CounterBox) GetFloatCounterScaled(name string, scale float64, decimals int) FloatCounter {
+	name = c.checkName(name)
+	value, ok := c.floats.Load(name)
+	if !ok {
+		value, _ = c.floats.LoadOrStore(name, &floatCounter{name: name, scale: scale, decimals: decimals})
+	}
+	v, _ := value.(FloatCounter)
+	return v
+}
+
+// FloatPair is a name and a value of a float counter.
+type FloatPair struct {
+	Name  string
+	Value float64
+	// Text is the value formatted for printing.
+	Text string
+}
+
+// FloatPairs returns names and values of all float counters sorted by name.
+func (c *CounterBox) FloatPairs() []FloatPair {
+	var pairs []FloatPair
+	c.floats.Range(func(key interface{}, value interface{}) bool {
+		if v, ok := value.(*floatCounter); ok {
+			pairs = append(pairs, FloatPair{v.name, v.Value(), v.text()})
+		}
+		return true
+	})
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+// FloatMaxMin is a minima or maxima counter of float values.
+type FloatMaxMin interface {
+	// Set updates the value if v is more extreme.
+	Set(v float64)
+	// Name returns a name of counter.
+	Name() string
+	// Value returns a current value.
+	Value() float64
+}
+
+// floatExtreme keeps bits of a float64 extreme value in an uint64.
+type floatExtreme struct {
+	name string
+	bits uint64
+	// better reports whether v should replace the current value.
+	better func(v, cur float64) bool
+}
+
+func (f *floatExtreme) Set(v float64) {
+	for {
+		o := atomic.LoadUint64(&f.bits)
+		if !f.better(v, math.Float64frombits(o)) {
+			return
+		}
+		if atomic.CompareAndSwapUint64(&f.bits, o, math.Float64bits(v)) {
+			return
+		}
+	}
+}
+
+func (f *floatExtreme) Name() string {
+	return f.name
+}
+
+func (f *floatExtreme) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&f.bits))
+}
+
+func (f *floatExtreme) store(v float64) {
+	atomic.StoreUint64(&f.bits, math.Float64bits(v))
+}
+
+func greater(v, cur float64) bool { return v > cur }
+
+func less(v, cur float64) bool { return v < cur }
+
+// GetFloatMax returns a maxima float counter of given name, if doesn't exist
+// than create. Like GetMax it starts at 0.
+func (c *CounterBox) GetFloatMax(name string) FloatMaxMin {
+	name = c.checkName(name)
+	value, ok := c.floatMax.Load(name)
+	if !ok {
+		value, _ = c.floatMax.LoadOrStore(name, &floatExtreme{name: name, better: greater})
+	}
+	v, _ := value.(FloatMaxMin)
+	return v
+}
+
+// GetFloatMin returns a minima float counter of given name, if doesn't exist
+// than create. It starts at +Inf, a minimum which was never set isn't
+// printed nor exported.
+func (c *CounterBox) GetFloatMin(name string) FloatMaxMin {
+	name = c.checkName(name)
+	value, ok := c.floatMin.Load(name)
+	if !ok {
+		value, _ = c.floatMin.LoadOrStore(name, &floatExtreme{name: name, bits: math.Float64bits(math.Inf(1)), better: less})
+	}
+	v, _ := value.(FloatMaxMin)
+	return v
+}
+
+// floatExtremePairs returns names and values of float extremes kept in m
+// sorted by name.
+func floatExtremePairs(m *sync.Map) []FloatPair {
+	var pairs []FloatPair
+	m.Range(func(key interface{}, value interface{}) bool {
+		if v, ok := value.(*floatExtreme); ok {
+			f := v.Value()
+			pairs = append(pairs, FloatPair{v.name, f, strconv.FormatFloat(f, 'f', -1, 64)})
+		}
+		return true
+	})
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+// FloatMinPairs returns names and values of all minima float counters
+// sorted by name, minima which were never set are omitted.
+func (c *CounterBox) FloatMinPairs() []FloatPair {
+	var pairs []FloatPair
+	for _, p := range floatExtremePairs(&c.floatMin) {
+		if !math.IsInf(p.Value, 1) {
+			pairs = append(pairs, p)
+		}
+	}
+	return pairs
+}
+
+// FloatMaxPairs returns names and values of all maxima float counters
+// sorted by name.
+func (c *CounterBox) FloatMaxPairs() []FloatPair {
+	return floatExtremePairs(&c.floatMax)
+}
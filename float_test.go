@@ -0,0 +1,133 @@
+package counters
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFloatCounter(t *testing.T) {
+	box := NewCounterBox()
+	var wg sync.WaitGroup
+	for x := 0; x < 10; x++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := 0; y < 100; y++ {
+				box.GetFloatCounter("test").Add(0.5)
+			}
+		}()
+	}
+	wg.Wait()
+	if v := box.GetFloatCounter("test").Value(); v != 500 {
+		t.Errorf("want: 500, got %f", v)
+	}
+}
+
+func TestFloatCounterScaled(t *testing.T) {
+	box := NewCounterBox()
+	latency := box.GetFloatCounterScaled("latency_ms", 1e-6, 2)
+	latency.Add(1234567.891)
+	box.GetFloatCounter("plain").Add(0.125)
+
+	if v := latency.Value(); v != 1234567.891 {
+		t.Errorf("internal value should keep precision, got %f", v)
+	}
+	want := []FloatPair{{"latency_ms", 1234567.891, "1.23"}, {"plain", 0.125, "0.125"}}
+	got := box.FloatPairs()
+	if len(got) != len(want) {
+		t.Fatalf("want: %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("want: %v, got %v", want[i], got[i])
+		}
+	}
+	if s := box.String(); !strings.HasSuffix(s, "== Float counters ==\n  latency_ms: 1.23\n  plain: 0.125") {
+		t.Errorf("output should contain float counters:\n%s", s)
+	}
+}
+
+func TestFloatMaxMin(t *testing.T) {
+	box := NewCounterBox()
+	var wg sync.WaitGroup
+	for x := 0; x < 10; x++ {
+		wg.Add(1)
+		go func(x int) {
+			defer wg.Done()
+			for y := 0; y < 100; y++ {
+				v := 1.25 + float64(x*100+y)*0.001
+				box.GetFloatMax("latency").Set(v)
+				box.GetFloatMin("latency").Set(v)
+			}
+		}(x)
+	}
+	wg.Wait()
+	if v := box.GetFloatMax("latency").Value(); math.Abs(v-2.249) > 1e-9 {
+		t.Errorf("max, want: 2.249, got %v", v)
+	}
+	if v := box.GetFloatMin("latency").Value(); v != 1.25 {
+		t.Errorf("min, want: 1.25, got %v", v)
+	}
+}
+
+func TestFloatMaxMinWriteTo(t *testing.T) {
+	box := NewCounterBox()
+	box.GetFloatMax("latency").Set(0.125)
+	box.GetFloatMax("latency").Set(0.0625)
+	box.GetFloatMin("latency").Set(0.5)
+	box.GetFloatMin("latency").Set(0.0625)
+	box.GetFloatMin("unused")
+	want := `== Counters ==
+== Min values ==
+== Max values ==
+== Float min values ==
+  latency: 0.0625
+== Float max values ==
+  latency: 0.125`
+	if s := box.String(); s != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, s)
+	}
+}
+
+func TestFloatMaxMinExport(t *testing.T) {
+	box := NewCounterBox()
+	box.GetFloatMax("latency").Set(0.125)
+	box.GetFloatMin("latency").Set(0.0625)
+	box.GetFloatMin("unused")
+
+	buf := &bytes.Buffer{}
+	if err := box.WritePrometheus(buf, WithoutMetadata()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "latency_min 0.0625\nlatency_max 0.125\n"; got != want {
+		t.Errorf("Prometheus, want:\n%s\ngot:\n%s", want, got)
+	}
+
+	wantFamilies := []MetricFamily{
+		{"latency_min", PrometheusGauge, "Min value of latency.", []Sample{{nil, 0.0625}}},
+		{"latency_max", PrometheusGauge, "Max value of latency.", []Sample{{nil, 0.125}}},
+	}
+	if got := box.MetricFamilies(); !reflect.DeepEqual(got, wantFamilies) {
+		t.Errorf("MetricFamilies, want: %v, got %v", wantFamilies, got)
+	}
+
+	data, err := json.Marshal(box)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"float_min":{"latency":0.0625},"float_max":{"latency":0.125}`; !strings.Contains(string(data), want) {
+		t.Errorf("JSON, want %s in %s", want, data)
+	}
+	restored, err := FromJSON(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := restored.String(), box.String(); got != want {
+		t.Errorf("after JSON, want:\n%s\ngot:\n%s", want, got)
+	}
+}
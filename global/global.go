@@ -55,8 +55,8 @@ func Default() counters.Counters {
 	return globalBox
 }
 
-func WriteTo(w io.Writer) {
-	globalBox.WriteTo(w)
+func WriteTo(w io.Writer) (int64, error) {
+	return globalBox.WriteTo(w)
 }
 
 func String() string {
@@ -0,0 +1,95 @@
+package counters
+
+import "sync"
+
+// resetter is implemented by counters which can be brought back to
+// their initial value.
+type resetter interface {
+	reset()
+}
+
+// Group is a named set of counters from a CounterBox which are reset
+// together.
+type Group struct {
+	box  *CounterBox
+	name string
+
+	mu       sync.Mutex
+	counters map[string]Counter
+	min      map[string]MaxMinValue
+	max      map[string]MaxMinValue
+}
+
+// Group returns a group of given name, if doesn't exist than create.
+func (c *CounterBox) Group(name string) *Group {
+	value, _ := c.groups.LoadOrStore(name, &Group{
+		box:      c,
+		name:     name,
+		counters: make(map[string]Counter),
+		min:      make(map[string]MaxMinValue),
+		max:      make(map[string]MaxMinValue),
+	})
+	g, _ := value.(*Group)
+	return g
+}
+
+// Name returns a name of group.
+func (g *Group) Name() string {
+	return g.name
+}
+
+// GetCounter returns a counter of given name from the box and adds it
+// to the group.
+func (g *Group) GetCounter(name string) Counter {
+	v := g.box.GetCounter(name)
+	g.mu.Lock()
+	g.counters[name] = v
+	g.mu.Unlock()
+	return v
+}
+
+// GetMin returns a minima counter of given name from the box and adds it
+// to the group.
+func (g *Group) GetMin(name string) MaxMinValue {
+	v := g.box.GetMin(name)
+	g.mu.Lock()
+	g.min[name] = v
+	g.mu.Unlock()
+	return v
+}
+
+// GetMax returns a maxima counter of given name from the box and adds it
+// to the group.
+func (g *Group) GetMax(name string) MaxMinValue {
+	v := g.box.GetMax(name)
+	g.mu.Lock()
+	g.max[name] = v
+	g.mu.Unlock()
+	return v
+}
+
+// ResetAll brings all members of the group back to their initial values.
+// The members are reset under the box lock, so operations reading several
+// counters at once see either all or none of them reset.
+func (g *Group) ResetAll() {
+	g.box.mu.Lock()
+	g.mu.Lock()
+	names := resetMembers(g.counters, nil)
+	names = resetMembers(g.min, names)
+	names = resetMembers(g.max, names)
+	g.mu.Unlock()
+	g.box.mu.Unlock()
+	g.box.notifyReset(names)
+}
+
+// resetMembers resets counters of m and appends names of the reset ones
+// to names.
+func resetMembers[T any](m map[string]T, names []string) []string {
+	for name, v := range m {
+		if r, ok := any(v).(resetter); ok {
+			r.reset()
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,36 @@
+package counters
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGroupResetAll(t *testing.T) {
+	box := NewCounterBox()
+	g := box.Group("request")
+	if box.Group("request") != g {
+		t.Errorf("Group should return the same group for the same name")
+	}
+	g.GetCounter("a").IncrementBy(3)
+	g.GetCounter("b").IncrementBy(5)
+	g.GetMin("min").Set(2)
+	g.GetMax("max").Set(7)
+	box.GetCounter("other").IncrementBy(4)
+
+	g.ResetAll()
+
+	for _, name := range []string{"a", "b"} {
+		if v := box.GetCounter(name).Value(); v != 0 {
+			t.Errorf("counter %s, want: 0, got %d", name, v)
+		}
+	}
+	if v := box.GetMin("min").Value(); v != math.MaxInt64 {
+		t.Errorf("min, want: %d, got %d", int64(math.MaxInt64), v)
+	}
+	if v := box.GetMax("max").Value(); v != 0 {
+		t.Errorf("max, want: 0, got %d", v)
+	}
+	if v := box.GetCounter("other").Value(); v != 4 {
+		t.Errorf("counter outside of group, want: 4, got %d", v)
+	}
+}
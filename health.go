@@ -0,0 +1,80 @@
+package counters
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Comparison tells how a HealthRule compares a value with its threshold.
+type Comparison int
+
+const (
+	// Above is violated by values greater than the threshold.
+	Above Comparison = iota
+	// AtLeast is violated by values greater than or equal to the threshold.
+	AtLeast
+	// Below is violated by values less than the threshold.
+	Below
+	// AtMost is violated by values less than or equal to the threshold.
+	AtMost
+)
+
+func (c Comparison) String() string {
+	switch c {
+	case Above:
+		return ">"
+	case AtLeast:
+		return ">="
+	case Below:
+		return "<"
+	case AtMost:
+		return "<="
+	}
+	return "?"
+}
+
+func (c Comparison) matches(v, threshold int64) bool {
+	switch c {
+	case Above:
+		return v > threshold
+	case AtLeast:
+		return v >= threshold
+	case Below:
+		return v < threshold
+	case AtMost:
+		return v <= threshold
+	}
+	return false
+}
+
+// HealthRule marks the box unhealthy when a value of counter Name compared
+// with Threshold matches Comparison, e.g. {"errors", 100, Above} fails when
+// there are more than 100 errors. A counter which doesn't exist has value 0.
+type HealthRule struct {
+	Name       string
+	Threshold  int64
+	Comparison Comparison
+}
+
+// HealthCheck creates a handler checking rules against a snapshot of
+// the box. If all rules pass it responds with 200 and "OK", otherwise with
+// 503 and a line per violated rule, e.g. "errors: 150 > 100".
+func (c *CounterBox) HealthCheck(rules []HealthRule) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		values := c.Snapshot().Counters
+		var b strings.Builder
+		for _, rule := range rules {
+			if v := values[rule.Name]; rule.Comparison.matches(v, rule.Threshold) {
+				fmt.Fprintf(&b, "%s: %d %s %d\n", rule.Name, v, rule.Comparison, rule.Threshold)
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if b.Len() > 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, b.String())
+			return
+		}
+		fmt.Fprintln(w, "OK")
+	}
+}
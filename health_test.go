@@ -0,0 +1,41 @@
+package counters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthCheck(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("errors").IncrementBy(150)
+	box.GetCounter("workers").IncrementBy(2)
+	tests := []struct {
+		rules  []HealthRule
+		status int
+		body   string
+	}{
+		{nil, http.StatusOK, "OK\n"},
+		{[]HealthRule{
+			{"errors", 200, Above},
+			{"workers", 1, Below},
+			{"missing", 1, AtLeast},
+		}, http.StatusOK, "OK\n"},
+		{[]HealthRule{
+			{"errors", 100, Above},
+			{"workers", 2, AtMost},
+			{"missing", 0, AtLeast},
+			{"workers", 3, Below},
+		}, http.StatusServiceUnavailable, "errors: 150 > 100\nworkers: 2 <= 2\nmissing: 0 >= 0\nworkers: 2 < 3\n"},
+	}
+	for i, tt := range tests {
+		rec := httptest.NewRecorder()
+		box.HealthCheck(tt.rules)(rec, httptest.NewRequest("GET", "/healthz", nil))
+		if rec.Code != tt.status {
+			t.Errorf("%d: status, want: %d, got %d", i, tt.status, rec.Code)
+		}
+		if got := rec.Body.String(); got != tt.body {
+			t.Errorf("%d: body, want: %q, got %q", i, tt.body, got)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package counters
+
+import (
+	"math/rand/v2"
+	"sort"
+	"sync"
+)
+
+// histogramShard keeps observations of a part of a histogram, padded so
+// shards don't share a cache line.
+type histogramShard struct {
+	mu sync.Mutex
+	// counts has one element per bound and one for the overflow bucket.
+	counts []int64
+	sum    int64
+	count  int64
+	_      [16]byte
+}
+
+// Histogram counts observed values in buckets with fixed upper bounds.
+type Histogram struct {
+	name string
+	// bounds are sorted upper bounds of buckets, a value v falls into
+	// the first bucket with v <= bound or into the overflow bucket.
+	bounds []int64
+
+	// shards has a single element unless the histogram is sharded,
+	// see WithShardedHistograms.
+	shards []histogramShard
+	mask   uint32
+}
+
+func newHistogram(name string, bounds []int64, shards int) *Histogram {
+	b := append([]int64(nil), bounds...)
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	h := &Histogram{name: name, bounds: b, shards: make([]histogramShard, shards), mask: uint32(shards - 1)}
+	for i := range h.shards {
+		// Extra capacity keeps counts of different shards from sharing
+		// a cache line.
+		h.shards[i].counts = make([]int64, len(b)+1, len(b)+9)
+	}
+	return h
+}
+
+// shard returns a shard to record an observation in.
+func (h *Histogram) shard() *histogramShard {
+	if h.mask == 0 {
+		return &h.shards[0]
+	}
+	return &h.shards[rand.Uint32()&h.mask]
+}
+
+// Observe records v in the bucket it falls into.
+func (h *Histogram) Observe(v int64) {
+	i := sort.Search(len(h.bounds), func(i int) bool { return v <= h.bounds[i] })
+	s := h.shard()
+	s.mu.Lock()
+	s.counts[i]++
+	s.sum += v
+	s.count++
+	s.mu.Unlock()
+}
+
+// Reset sets counts of all buckets, the sum and the count to 0. The bucket
+// bounds are kept.
+func (h *Histogram) Reset() {
+	for i := range h.shards {
+		s := &h.shards[i]
+		s.mu.Lock()
+		for j := range s.counts {
+			s.counts[j] = 0
+		}
+		s.sum = 0
+		s.count = 0
+		s.mu.Unlock()
+	}
+}
+
+// HistogramSnapshot keeps values of a histogram at some moment.
+type HistogramSnapshot struct {
+	// Bounds are upper bounds of buckets in increasing order.
+	Bounds []int64
+	// Counts has the number of values in every bucket, the last element is
+	// the number of values greater than all bounds.
+	Counts []int64
+	Sum    int64
+	Count  int64
+}
+
+// SnapshotAndReset returns counts of all buckets, the sum and the count and
+// sets them to 0 in one operation. All shards are locked at once, so every
+// observation is either in the snapshot or kept in the histogram, it's
+// never lost or reported twice.
+func (h *Histogram) SnapshotAndReset() HistogramSnapshot {
+	s := HistogramSnapshot{Bounds: h.Bounds(), Counts: make([]int64, len(h.bounds)+1)}
+	for i := range h.shards {
+		h.shards[i].mu.Lock()
+	}
+	for i := range h.shards {
+		sh := &h.shards[i]
+		for j, n := range sh.counts {
+			s.Counts[j] += n
+			sh.counts[j] = 0
+		}
+		s.Sum += sh.sum
+		s.Count += sh.count
+		sh.sum, sh.count = 0, 0
+	}
+	for i := range h.shards {
+		h.shards[i].mu.Unlock()
+	}
+	return s
+}
+
+// Name returns a name of histogram.
+func (h *Histogram) Name() string {
+	return h.name
+}
+
+// Bounds returns upper bounds of buckets in increasing order.
+func (h *Histogram) Bounds() []int64 {
+	return append([]int64(nil), h.bounds...)
+}
+
+// Counts returns the number of values in every bucket, the last element
+// is the number of values greater than all bounds.
+func (h *Histogram) Counts() []int64 {
+	counts := make([]int64, len(h.bounds)+1)
+	for i := range h.shards {
+		s := &h.shards[i]
+		s.mu.Lock()
+		for j, n := range s.counts {
+			counts[j] += n
+		}
+		s.mu.Unlock()
+	}
+	return counts
+}
+
+// Sum returns the sum of observed values.
+func (h *Histogram) Sum() int64 {
+	var sum int64
+	for i := range h.shards {
+		s := &h.shards[i]
+		s.mu.Lock()
+		sum += s.sum
+		s.mu.Unlock()
+	}
+	return sum
+}
+
+// Count returns the number of observed values.
+func (h *Histogram) Count() int64 {
+	var count int64
+	for i := range h.shards {
+		s := &h.shards[i]
+		s.mu.Lock()
+		count += s.count
+		s.mu.Unlock()
+	}
+	return count
+}
+
+// GetHistogram returns a histogram of given name, if doesn't exist than
+// create it with given bucket bounds. Bounds of an existing histogram are
+// not changed. Histograms of a sharded box are read shard by shard, so
+// a read concurrent with observations may see counts, the sum and the count
+// of slightly different moments.
+func (c *CounterBox) GetHistogram(name string, bounds ...int64) *Histogram {
+	name = c.checkName(name)
+	value, ok := c.histograms.Load(name)
+	if !ok {
+		shards := 1
+		if c.shardedHistograms {
+			shards = numShards()
+		}
+		value, _ = c.histograms.LoadOrStore(name, newHistogram(name, bounds, shards))
+	}
+	h, _ := value.(*Histogram)
+	return h
+}
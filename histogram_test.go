@@ -0,0 +1,187 @@
+package counters
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestHistogramObserve(t *testing.T) {
+	box := NewCounterBox()
+	h := box.GetHistogram("latency", 100, 10, 50)
+	for _, v := range []int64{1, 10, 11, 50, 99, 1000} {
+		h.Observe(v)
+	}
+	if got, want := h.Bounds(), []int64{10, 50, 100}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bounds, want: %v, got %v", want, got)
+	}
+	if got, want := h.Counts(), []int64{2, 2, 1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("counts, want: %v, got %v", want, got)
+	}
+	if v := h.Sum(); v != 1171 {
+		t.Errorf("sum, want: 1171, got %d", v)
+	}
+	if v := h.Count(); v != 6 {
+		t.Errorf("count, want: 6, got %d", v)
+	}
+	if box.GetHistogram("latency") != h {
+		t.Error("want the same histogram for the same name")
+	}
+}
+
+func TestHistogramReset(t *testing.T) {
+	box := NewCounterBox()
+	h := box.GetHistogram("latency", 10, 50)
+	h.Observe(5)
+	h.Observe(20)
+	h.Observe(70)
+	h.Reset()
+	h.Observe(40)
+	h.Observe(45)
+
+	if got, want := h.Bounds(), []int64{10, 50}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bounds, want: %v, got %v", want, got)
+	}
+	if got, want := h.Counts(), []int64{0, 2, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("counts, want: %v, got %v", want, got)
+	}
+	if v := h.Sum(); v != 85 {
+		t.Errorf("sum, want: 85, got %d", v)
+	}
+	if v := h.Count(); v != 2 {
+		t.Errorf("count, want: 2, got %d", v)
+	}
+}
+
+func TestHistogramResetConcurrent(t *testing.T) {
+	h := NewCounterBox().GetHistogram("latency", 10)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				h.Observe(int64(j % 20))
+				if j%100 == 0 {
+					h.Reset()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	var sum int64
+	for _, n := range h.Counts() {
+		sum += n
+	}
+	if c := h.Count(); c != sum {
+		t.Errorf("count, want: %d, got %d", sum, c)
+	}
+}
+
+func TestShardedHistogram(t *testing.T) {
+	box := NewCounterBox(WithShardedHistograms())
+	h := box.GetHistogram("latency", 10, 100)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int64(0); i < 1000; i++ {
+				h.Observe(i % 200)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every goroutine observes 0..199 five times: 11 values <= 10,
+	// 90 in (10, 100] and 99 above 100.
+	if want, got := []int64{8 * 5 * 11, 8 * 5 * 90, 8 * 5 * 99}, h.Counts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+	if got := h.Count(); got != 8000 {
+		t.Errorf("want: 8000, got %d", got)
+	}
+	if want, got := int64(8*5*199*200/2), h.Sum(); got != want {
+		t.Errorf("want: %d, got %d", want, got)
+	}
+	h.Reset()
+	if got := h.Count(); got != 0 {
+		t.Errorf("want: 0 after reset, got %d", got)
+	}
+}
+
+func BenchmarkHistogramParallel(b *testing.B) {
+	h := NewCounterBox().GetHistogram("test", 10, 100, 1000)
+	b.RunParallel(func(pb *testing.PB) {
+		for v := int64(0); pb.Next(); v++ {
+			h.Observe(v & 1023)
+		}
+	})
+}
+
+func BenchmarkShardedHistogramParallel(b *testing.B) {
+	h := NewCounterBox(WithShardedHistograms()).GetHistogram("test", 10, 100, 1000)
+	b.RunParallel(func(pb *testing.PB) {
+		for v := int64(0); pb.Next(); v++ {
+			h.Observe(v & 1023)
+		}
+	})
+}
+
+func TestHistogramSnapshotAndReset(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithShardedHistograms()}} {
+		h := NewCounterBox(opts...).GetHistogram("latency", 10)
+		h.Observe(5)
+		h.Observe(50)
+		want := HistogramSnapshot{Bounds: []int64{10}, Counts: []int64{1, 1}, Sum: 55, Count: 2}
+		if got := h.SnapshotAndReset(); !reflect.DeepEqual(got, want) {
+			t.Errorf("want: %+v, got %+v", want, got)
+		}
+		if c := h.Count(); c != 0 {
+			t.Errorf("want 0 after snapshot, got %d", c)
+		}
+
+		const goroutines, n = 4, 10000
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < n; i++ {
+					h.Observe(int64(i % 20))
+				}
+			}()
+		}
+		var total HistogramSnapshot
+		total.Counts = make([]int64, 2)
+		add := func(s HistogramSnapshot) {
+			for i, c := range s.Counts {
+				total.Counts[i] += c
+			}
+			total.Sum += s.Sum
+			total.Count += s.Count
+		}
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		for running := true; running; {
+			select {
+			case <-done:
+				running = false
+			default:
+			}
+			add(h.SnapshotAndReset())
+		}
+		if total.Count != goroutines*n {
+			t.Errorf("want %d observations, got %d", goroutines*n, total.Count)
+		}
+		if want := []int64{goroutines * n * 11 / 20, goroutines * n * 9 / 20}; !reflect.DeepEqual(total.Counts, want) {
+			t.Errorf("want counts: %v, got %v", want, total.Counts)
+		}
+		if want := int64(goroutines * n / 20 * 190); total.Sum != want {
+			t.Errorf("want sum: %d, got %d", want, total.Sum)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package counters
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PathNormalizer returns a path under which a request is counted. It allows
+// to collapse paths containing identifiers, e.g. /users/123 to /users/:id.
+type PathNormalizer func(*http.Request) string
+
+func urlPath(r *http.Request) string {
+	return r.URL.Path
+}
+
+// NewRegexpNormalizer creates a PathNormalizer replacing all matches of
+// pattern in the URL path with repl, see regexp.Regexp.ReplaceAllString.
+// It panics if pattern doesn't compile.
+func NewRegexpNormalizer(pattern, repl string) PathNormalizer {
+	re := regexp.MustCompile(pattern)
+	return func(r *http.Request) string {
+		return re.ReplaceAllString(r.URL.Path, repl)
+	}
+}
+
+type middlewareOptions struct {
+	normalizer PathNormalizer
+}
+
+// MiddlewareOption configures a handler created by CountRequests.
+type MiddlewareOption func(*middlewareOptions)
+
+// WithPathNormalizer sets a function naming the counted paths, by default
+// the URL path is used as it is.
+func WithPathNormalizer(n PathNormalizer) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.normalizer = n
+	}
+}
+
+// CountRequests wraps next with a handler counting requests per path in
+// counters named http.requests.<path>.
+func (c *CounterBox) CountRequests(next http.Handler, opts ...MiddlewareOption) http.Handler {
+	o := &middlewareOptions{normalizer: urlPath}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.GetCounter("http.requests." + o.normalizer(r)).Increment()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CreateValueHandler creates a handler printing a value of a single counter
+// followed by a newline. The name is taken from the name query parameter or,
+// if it's not set, from the URL path without the leading slash, use
+// http.StripPrefix to mount the handler under a path. The type query
+// parameter selects counter (default), min or max. It responds with
+// 404 if the counter doesn't exist.
+func (c *CounterBox) CreateValueHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		name := q.Get("name")
+		if name == "" {
+			name = strings.TrimPrefix(r.URL.Path, "/")
+		}
+		var peek func(string) (int64, bool)
+		switch q.Get("type") {
+		case "", "counter":
+			peek = c.PeekCounter
+		case "min":
+			peek = c.PeekMin
+		case "max":
+			peek = c.PeekMax
+		default:
+			http.Error(w, "unknown type", http.StatusBadRequest)
+			return
+		}
+		v, ok := peek(name)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintf(w, "%d\n", v)
+	}
+}
+
+// responseRecorder wraps http.ResponseWriter counting written bytes and
+// remembering the status code.
+type responseRecorder struct {
+	http.ResponseWriter
+	bytes    Counter
+	status   int
+	hijacked bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes.IncrementBy(n)
+	return n, err
+}
+
+// Unwrap returns the wrapped writer, it's used by http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+type flushRecorder struct{ *responseRecorder }
+
+func (r flushRecorder) Flush() {
+	r.ResponseWriter.(http.Flusher).Flush()
+}
+
+type hijackRecorder struct{ *responseRecorder }
+
+func (r hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.hijack()
+}
+
+type flushHijackRecorder struct{ *responseRecorder }
+
+func (r flushHijackRecorder) Flush() {
+	r.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (r flushHijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.hijack()
+}
+
+// hijack hijacks the wrapped writer and remembers it succeeded.
+func (r *responseRecorder) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := r.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		r.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// wrap returns r as a writer implementing http.Flusher and http.Hijacker
+// if the wrapped writer implements them.
+func (r *responseRecorder) wrap() http.ResponseWriter {
+	_, flusher := r.ResponseWriter.(http.Flusher)
+	_, hijacker := r.ResponseWriter.(http.Hijacker)
+	switch {
+	case flusher && hijacker:
+		return flushHijackRecorder{r}
+	case flusher:
+		return flushRecorder{r}
+	case hijacker:
+		return hijackRecorder{r}
+	}
+	return r
+}
+
+// InstrumentHandler wraps next with a handler counting bytes of response
+// bodies in counter name.bytes and responses per status code in counters
+// name.status.<code>, e.g. name.status.404. Responses without an explicit
+// status are counted as 200. Hijacked connections are not counted. The
+// writer passed to next implements http.Flusher and http.Hijacker if the
+// original one does.
+func (c *CounterBox) InstrumentHandler(name string, next http.Handler) http.Handler {
+	bytes := c.GetCounter(name + ".bytes")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &responseRecorder{ResponseWriter: w, bytes: bytes}
+		next.ServeHTTP(rec.wrap(), r)
+		if rec.hijacked {
+			return
+		}
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		c.GetCounter(name + ".status." + strconv.Itoa(status)).Increment()
+	})
+}
@@ -0,0 +1,223 @@
+package counters
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCreateHttpHandlerScrapeDuration(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	h := box.CreateHttpHandler()
+	w := &slowWriter{httptest.NewRecorder(), clock}
+	h(w, httptest.NewRequest("GET", "/status", nil))
+	if v, _ := box.PeekCounter(ScrapeDuration + ".count"); v != 1 {
+		t.Errorf("count, want: 1, got %d", v)
+	}
+	h(w, httptest.NewRequest("GET", "/status", nil))
+	if v, _ := box.PeekCounter(ScrapeDuration + ".count"); v != 2 {
+		t.Errorf("count, want: 2, got %d", v)
+	}
+	if v, _ := box.PeekCounter(ScrapeDuration + ".total"); v <= 0 {
+		t.Errorf("total should grow, got %d", v)
+	}
+	if v, ok := box.PeekMax(ScrapeDuration); !ok || v <= 0 {
+		t.Errorf("max should be recorded, got %d", v)
+	}
+}
+
+// slowWriter advances the clock on every write.
+type slowWriter struct {
+	*httptest.ResponseRecorder
+	clock *fakeClock
+}
+
+func (s *slowWriter) Write(p []byte) (int, error) {
+	s.clock.Advance(time.Millisecond)
+	return s.ResponseRecorder.Write(p)
+}
+
+func TestCreateHttpHandlerNonZero(t *testing.T) {
+	box := NewCounterBox()
+	box.DeclareCounter("idle")
+	box.GetCounter("active").Increment()
+	box.DeclareMin("idle")
+	box.GetMin("active").Set(3)
+	box.DeclareMax("idle")
+	box.GetMax("active").Set(4)
+
+	rec := httptest.NewRecorder()
+	box.CreateHttpHandler()(rec, httptest.NewRequest("GET", "/status?nonzero=1", nil))
+	want := `== Counters ==
+  active: 1
+== Min values ==
+  active: 3
+== Max values ==
+  active: 4`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestCountRequests(t *testing.T) {
+	box := NewCounterBox()
+	h := box.CountRequests(http.NotFoundHandler())
+	for _, path := range []string{"/a", "/a", "/b"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	want := []CounterPair{{"http.requests./a", 2}, {"http.requests./b", 1}}
+	if got := box.Pairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+}
+
+func TestCountRequestsNormalized(t *testing.T) {
+	box := NewCounterBox()
+	h := box.CountRequests(http.NotFoundHandler(),
+		WithPathNormalizer(NewRegexpNormalizer(`/\d+`, "/:id")))
+	for _, path := range []string{"/users/1", "/users/23", "/users/456/posts/7"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	want := []CounterPair{{"http.requests./users/:id", 2}, {"http.requests./users/:id/posts/:id", 1}}
+	if got := box.Pairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+}
+
+func TestCreateValueHandler(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("foo").IncrementBy(5)
+	box.GetMax("foo").Set(9)
+	box.GetMin("bar").Set(2)
+	h := http.StripPrefix("/metric", box.CreateValueHandler())
+
+	for _, tc := range []struct {
+		url  string
+		code int
+		body string
+	}{
+		{"/metric/foo", http.StatusOK, "5\n"},
+		{"/metric?name=foo", http.StatusOK, "5\n"},
+		{"/metric/foo?type=max", http.StatusOK, "9\n"},
+		{"/metric/bar?type=min", http.StatusOK, "2\n"},
+		{"/metric/bar", http.StatusNotFound, ""},
+		{"/metric/missing", http.StatusNotFound, ""},
+		{"/metric/foo?type=other", http.StatusBadRequest, ""},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", tc.url, nil))
+		if rec.Code != tc.code {
+			t.Errorf("%s: want code %d, got %d", tc.url, tc.code, rec.Code)
+		}
+		if tc.body != "" && rec.Body.String() != tc.body {
+			t.Errorf("%s: want body %q, got %q", tc.url, tc.body, rec.Body.String())
+		}
+	}
+	if _, ok := box.PeekCounter("missing"); ok {
+		t.Errorf("handler should not create missing counters")
+	}
+}
+
+func TestInstrumentHandler(t *testing.T) {
+	box := NewCounterBox()
+	h := box.InstrumentHandler("api", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/nothing":
+		default:
+			w.Write([]byte("hello"))
+			w.Write([]byte(" world"))
+		}
+	}))
+	for _, path := range []string{"/", "/", "/missing", "/empty", "/nothing"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	want := map[string]int64{
+		"api.bytes":      2*11 + int64(len("404 page not found\n")),
+		"api.status.200": 3,
+		"api.status.204": 1,
+		"api.status.404": 1,
+	}
+	if got := box.Snapshot().Counters; !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+}
+
+// hijackWriter is a writer implementing http.Hijacker but not http.Flusher,
+// Hijack fails if the writer is nil.
+type hijackWriter struct {
+	http.ResponseWriter
+}
+
+func (w hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.ResponseWriter == nil {
+		return nil, nil, errors.New("not supported")
+	}
+	return nil, nil, nil
+}
+
+func TestInstrumentHandlerHijacked(t *testing.T) {
+	box := NewCounterBox()
+	h := box.InstrumentHandler("api", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, err := w.(http.Hijacker).Hijack(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}))
+	h.ServeHTTP(hijackWriter{httptest.NewRecorder()}, httptest.NewRequest("GET", "/", nil))
+	h.ServeHTTP(struct {
+		http.ResponseWriter
+		http.Flusher
+		http.Hijacker
+	}{httptest.NewRecorder(), httptest.NewRecorder(), hijackWriter{httptest.NewRecorder()}}, httptest.NewRequest("GET", "/", nil))
+	h.ServeHTTP(struct {
+		http.ResponseWriter
+		http.Hijacker
+	}{httptest.NewRecorder(), hijackWriter{}}, httptest.NewRequest("GET", "/", nil))
+	want := map[string]int64{
+		"api.bytes":      int64(len("not supported\n")),
+		"api.status.500": 1,
+	}
+	if got := box.Snapshot().Counters; !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+}
+
+func TestInstrumentHandlerInterfaces(t *testing.T) {
+	tests := []struct {
+		name              string
+		w                 http.ResponseWriter
+		flusher, hijacker bool
+	}{
+		{"recorder", httptest.NewRecorder(), true, false},
+		{"hijacker", hijackWriter{httptest.NewRecorder()}, false, true},
+		{"both", struct {
+			http.ResponseWriter
+			http.Flusher
+			http.Hijacker
+		}{httptest.NewRecorder(), httptest.NewRecorder(), hijackWriter{}}, true, true},
+		{"none", struct{ http.ResponseWriter }{httptest.NewRecorder()}, false, false},
+	}
+	for _, tt := range tests {
+		var flusher, hijacker bool
+		h := NewCounterBox().InstrumentHandler("api", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, flusher = w.(http.Flusher)
+			_, hijacker = w.(http.Hijacker)
+		}))
+		h.ServeHTTP(tt.w, httptest.NewRequest("GET", "/", nil))
+		if flusher != tt.flusher {
+			t.Errorf("%s: flusher, want: %v, got %v", tt.name, tt.flusher, flusher)
+		}
+		if hijacker != tt.hijacker {
+			t.Errorf("%s: hijacker, want: %v, got %v", tt.name, tt.hijacker, hijacker)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package counters
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+)
+
+// WriteInflux writes all counters in the InfluxDB line protocol, one line
+// per counter, e.g.
+//
+//	measurement,name=requests value=5i 1577836800000000000
+//
+// The counter name is a tag followed by labels given to Count, tags set by
+// SetTags and labels set by WithConstLabels, sorted by name. Counters
+// created by Count get their base name as the name. Counters use a value
+// field, minima and maxima counters use min and max fields. The timestamp
+// is taken from the box clock.
+func (c *CounterBox) WriteInflux(w io.Writer, measurement string) error {
+	m := influxMeasurementEscaper.Replace(measurement)
+	ts := c.clock.Now().UnixNano()
+	buf := &bytes.Buffer{}
+	write := func(pairs []CounterPair, field string) {
+		for _, p := range pairs {
+			base, labels := c.exportLabels(p.Name)
+			fmt.Fprintf(buf, "%s,name=%s", m, influxTagEscaper.Replace(base))
+			for _, t := range labels {
+				fmt.Fprintf(buf, ",%s=%s", influxTagEscaper.Replace(t.Name), influxTagEscaper.Replace(t.Value))
+			}
+			fmt.Fprintf(buf, " %s=%di %d\n", field, p.Value, ts)
+		}
+	}
+	write(c.Pairs(), "value")
+	write(c.MinPairs(), "min")
+	write(c.MaxPairs(), "max")
+	_, err := buf.WriteTo(w)
+	return err
+}
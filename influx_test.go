@@ -0,0 +1,40 @@
+package counters
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteInflux(t *testing.T) {
+	box := NewCounterBox(WithClock(newFakeClock()))
+	box.GetCounter("requests").IncrementBy(5)
+	box.GetCounter("a b,c=d").IncrementBy(1)
+	box.GetMin("latency").Set(3)
+	box.GetMax("latency").Set(9)
+
+	buf := &strings.Builder{}
+	if err := box.WriteInflux(buf, "my app"); err != nil {
+		t.Fatal(err)
+	}
+	want := `my\ app,name=a\ b\,c\=d value=1i 1577836800000000000
+my\ app,name=requests value=5i 1577836800000000000
+my\ app,name=latency min=3i 1577836800000000000
+my\ app,name=latency max=9i 1577836800000000000
+`
+	if got := buf.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestWriteInfluxLabeled(t *testing.T) {
+	box := NewCounterBox(WithClock(newFakeClock()), WithConstLabels(map[string]string{"service": "x"}))
+	box.Count("req", "method", "GET")
+	buf := &strings.Builder{}
+	if err := box.WriteInflux(buf, "app"); err != nil {
+		t.Fatal(err)
+	}
+	want := "app,name=req,method=GET,service=x value=1i 1577836800000000000\n"
+	if got := buf.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
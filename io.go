@@ -0,0 +1,37 @@
+package counters
+
+import "io"
+
+type counterWriter struct {
+	w       io.Writer
+	counter Counter
+}
+
+func (cw *counterWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.counter.IncrementBy(n)
+	return n, err
+}
+
+type counterReader struct {
+	r       io.Reader
+	counter Counter
+}
+
+func (cr *counterReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.counter.IncrementBy(n)
+	return n, err
+}
+
+// CountingWriter returns a writer which writes to w and adds the number of
+// written bytes to counter name. Bytes of partial writes are counted too.
+func (c *CounterBox) CountingWriter(name string, w io.Writer) io.Writer {
+	return &counterWriter{w, c.GetCounter(name)}
+}
+
+// CountingReader returns a reader which reads from r and adds the number
+// of read bytes to counter name.
+func (c *CounterBox) CountingReader(name string, r io.Reader) io.Reader {
+	return &counterReader{r, c.GetCounter(name)}
+}
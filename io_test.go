@@ -0,0 +1,65 @@
+package counters
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCountingWriter(t *testing.T) {
+	box := NewCounterBox()
+	var buf bytes.Buffer
+	w := box.CountingWriter("out", &buf)
+	n, err := io.Copy(w, strings.NewReader(strings.Repeat("x", 10000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := box.GetCounter("out").Value(); v != n || v != 10000 {
+		t.Errorf("want: 10000, got %d", v)
+	}
+	if _, err := w.Write([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	if v := box.GetCounter("out").Value(); v != 10003 {
+		t.Errorf("want: 10003, got %d", v)
+	}
+}
+
+// shortWriter accepts at most limit bytes in total.
+type shortWriter struct {
+	limit int
+}
+
+func (s *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > s.limit {
+		n := s.limit
+		s.limit = 0
+		return n, io.ErrShortWrite
+	}
+	s.limit -= len(p)
+	return len(p), nil
+}
+
+func TestCountingWriterPartial(t *testing.T) {
+	box := NewCounterBox()
+	w := box.CountingWriter("out", &shortWriter{limit: 5})
+	if n, err := w.Write([]byte("abcdefgh")); n != 5 || err != io.ErrShortWrite {
+		t.Errorf("want 5 and %v, got %d and %v", io.ErrShortWrite, n, err)
+	}
+	if v := box.GetCounter("out").Value(); v != 5 {
+		t.Errorf("want: 5, got %d", v)
+	}
+}
+
+func TestCountingReader(t *testing.T) {
+	box := NewCounterBox()
+	r := box.CountingReader("in", strings.NewReader(strings.Repeat("x", 10000)))
+	n, err := io.Copy(io.Discard, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := box.GetCounter("in").Value(); v != n || v != 10000 {
+		t.Errorf("want: 10000, got %d", v)
+	}
+}
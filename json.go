@@ -0,0 +1,256 @@
+package counters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// jsonBox is a JSON representation of a CounterBox.
+type jsonBox struct {
+	Counters map[string]jsonCounter       `json:"counters"`
+	Min      map[string]int64             `json:"min"`
+	Max      map[string]int64             `json:"max"`
+	FloatMin map[string]float64           `json:"float_min,omitempty"`
+	FloatMax map[string]float64           `json:"float_max,omitempty"`
+	Tags     map[string]map[string]string `json:"tags,omitempty"`
+	// Labels are const labels of all metrics.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// jsonCounter is a JSON representation of a counter. A counter without
+// labels is represented by its value, a labeled one by an object with
+// labels and value.
+type jsonCounter struct {
+	Labels map[string]string `json:"labels"`
+	Value  int64             `json:"value"`
+}
+
+// jsonLabeled has the fields of jsonCounter without its methods.
+type jsonLabeled jsonCounter
+
+func (j jsonCounter) MarshalJSON() ([]byte, error) {
+	if len(j.Labels) == 0 {
+		return json.Marshal(j.Value)
+	}
+	return json.Marshal(jsonLabeled(j))
+}
+
+func (j *jsonCounter) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '{' {
+		*j = jsonCounter{}
+		return json.Unmarshal(data, &j.Value)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode((*jsonLabeled)(j))
+}
+
+// jsonCounters returns counters of the box, labeled counters get their
+// labels.
+func (c *CounterBox) jsonCounters() map[string]jsonCounter {
+	pairs := c.Pairs()
+	m := make(map[string]jsonCounter, len(pairs))
+	for _, p := range pairs {
+		j := jsonCounter{Value: p.Value}
+		if ln, ok := c.labels.Load(p.Name); ok {
+			j.Labels = make(map[string]string)
+			for _, l := range ln.(*labeledName).labels {
+				j.Labels[l.Name] = l.Value
+			}
+		}
+		m[c.outputPrefix+p.Name] = j
+	}
+	return m
+}
+
+func pairsToMap(pairs []CounterPair) map[string]int64 {
+	return prefixedMap("", pairs)
+}
+
+// prefixedMap returns values of pairs by their names prefixed with prefix.
+func prefixedMap(prefix string, pairs []CounterPair) map[string]int64 {
+	m := make(map[string]int64, len(pairs))
+	for _, p := range pairs {
+		m[prefix+p.Name] = p.Value
+	}
+	return m
+}
+
+// prefixedFloatMap returns values of pairs by their names prefixed with
+// prefix, it returns nil if there are no pairs.
+func prefixedFloatMap(prefix string, pairs []FloatPair) map[string]float64 {
+	if len(pairs) == 0 {
+		return nil
+	}
+	m := make(map[string]float64, len(pairs))
+	for _, p := range pairs {
+		m[prefix+p.Name] = p.Value
+	}
+	return m
+}
+
+// MarshalJSON implements json.Marshaler. The output is an object with
+// counters, min and max objects mapping names to values. Counters created
+// with labels, see Count, are objects with labels and value fields, e.g.
+// {"requests{method=\"GET\"}":{"labels":{"method":"GET"},"value":3}}.
+// Float min and max values are kept in float_min and float_max objects,
+// which are omitted if there are none.
+// Names get the prefix set by WithOutputPrefix. Tags set by SetTags are kept
+// in a tags object mapping names to tags, it's omitted if there are none.
+// Labels set by WithConstLabels, which apply to every metric, are kept in
+// a labels object.
+func (c *CounterBox) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonBox{
+		Counters: c.jsonCounters(),
+		Min:      prefixedMap(c.outputPrefix, c.MinPairs()),
+		Max:      prefixedMap(c.outputPrefix, c.MaxPairs()),
+		FloatMin: prefixedFloatMap(c.outputPrefix, c.FloatMinPairs()),
+		FloatMax: prefixedFloatMap(c.outputPrefix, c.FloatMaxPairs()),
+		Tags:     c.jsonTags(),
+		Labels:   c.constLabels,
+	})
+}
+
+// jsonTags returns tags of the box by names with the output prefix.
+func (c *CounterBox) jsonTags() map[string]map[string]string {
+	all := c.allTags()
+	if c.outputPrefix == "" || all == nil {
+		return all
+	}
+	prefixed := make(map[string]map[string]string, len(all))
+	for name, tags := range all {
+		prefixed[c.outputPrefix+name] = tags
+	}
+	return prefixed
+}
+
+// storer is implemented by counters which value can be set as it is.
+type storer interface {
+	store(int64)
+}
+
+func (c *counterImpl) store(v int64) {
+	c.touch()
+	atomic.StoreInt64(&c.value, v)
+}
+
+func (m *maxImpl) store(v int64) {
+	atomic.StoreInt64(&m.value, v)
+}
+
+func (m *minImpl) store(v int64) {
+	atomic.StoreInt64(&m.value, v)
+}
+
+func storeValue(v interface{}, value int64) {
+	if s, ok := v.(storer); ok {
+		s.store(value)
+	}
+}
+
+// UnmarshalJSON implements json.Unmarshaler, it accepts the output of
+// MarshalJSON. Counters, min and max values, also float ones, are set to
+// the decoded values, other counters of the box stay untouched. The prefix
+// set by WithOutputPrefix is removed from names. Const labels are ignored,
+// the box keeps its own, see WithConstLabels.
+func (c *CounterBox) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var v jsonBox
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("counters: cannot decode JSON: %w", err)
+	}
+	if c.counters == nil {
+		c.counters, c.min, c.max = &sync.Map{}, &sync.Map{}, &sync.Map{}
+	}
+	if c.clock == nil {
+		c.clock = realClock{}
+	}
+	for name, value := range v.Counters {
+		name = strings.TrimPrefix(name, c.outputPrefix)
+		if len(value.Labels) > 0 {
+			ls := make([]string, 0, 2*len(value.Labels))
+			for k, v := range value.Labels {
+				ls = append(ls, k, v)
+			}
+			base, _, _ := strings.Cut(name, "{")
+			c.labels.LoadOrStore(name, &labeledName{base, parseLabels(ls)})
+		}
+		storeValue(c.GetCounter(name), value.Value)
+	}
+	for name, value := range v.Min {
+		storeValue(c.GetMin(strings.TrimPrefix(name, c.outputPrefix)), value)
+	}
+	for name, value := range v.Max {
+		storeValue(c.GetMax(strings.TrimPrefix(name, c.outputPrefix)), value)
+	}
+	for name, value := range v.FloatMin {
+		if f, ok := c.GetFloatMin(strings.TrimPrefix(name, c.outputPrefix)).(*floatExtreme); ok {
+			f.store(value)
+		}
+	}
+	for name, value := range v.FloatMax {
+		if f, ok := c.GetFloatMax(strings.TrimPrefix(name, c.outputPrefix)).(*floatExtreme); ok {
+			f.store(value)
+		}
+	}
+	for name, tags := range v.Tags {
+		c.SetTags(strings.TrimPrefix(name, c.outputPrefix), tags)
+	}
+	return nil
+}
+
+// FromJSON creates a new box with values read from r, the input should be
+// created by MarshalJSON.
+func FromJSON(r io.Reader, opts ...Option) (*CounterBox, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	c := NewCounterBox(opts...)
+	if err := c.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// boxVar is a whole box as an expvar.Var.
+type boxVar struct {
+	c *CounterBox
+}
+
+func (v boxVar) String() string {
+	data, err := v.c.MarshalJSON()
+	if err != nil {
+		return "null"
+	}
+	return string(data)
+}
+
+// Var returns the box as a value implementing expvar.Var, its String is the
+// output of MarshalJSON, e.g. expvar.Publish("counters", box.Var()) shows
+// the box as a nested object in /debug/vars. The package doesn't import
+// expvar, so programs not using it don't get its handler.
+func (c *CounterBox) Var() fmt.Stringer {
+	return boxVar{c}
+}
+
+// CreateJSONHandler creates a handler printing values of all counters as
+// JSON, see MarshalJSON.
+func (c *CounterBox) CreateJSONHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := c.MarshalJSON()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
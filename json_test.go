@@ -0,0 +1,127 @@
+package counters
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("a").IncrementBy(3)
+	box.GetCounter("b").DecrementBy(2)
+	box.GetMin("min").Set(-7)
+	box.GetMax("max").Set(11)
+
+	data, err := json.Marshal(box)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := FromJSON(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := restored.String(), box.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+
+	var decoded *CounterBox
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := decoded.Pairs(), box.Pairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+}
+
+func TestCreateJSONHandler(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("plain").IncrementBy(2)
+	box.Count("requests", "method", "GET", "status", "200")
+	box.Count("requests", "method", "GET", "status", "200")
+
+	rec := httptest.NewRecorder()
+	box.CreateJSONHandler()(rec, httptest.NewRequest("GET", "/json", nil))
+	want := `{"counters":{"plain":2,"requests{method=\"GET\",status=\"200\"}":` +
+		`{"labels":{"method":"GET","status":"200"},"value":2}},"min":{},"max":{}}`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+
+	restored, err := FromJSON(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(restored)
+	if string(data) != want {
+		t.Errorf("labels should survive a round trip, got:\n%s", data)
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	for _, in := range []string{
+		`{"counters":{"a":"x"}}`,
+		`{"counters":{"a":1.5}}`,
+		`{"other":{}}`,
+		`{"counters":`,
+		`{"counters":{"a":{"value":1,"other":2}}}`,
+	} {
+		if _, err := FromJSON(strings.NewReader(in)); err == nil {
+			t.Errorf("FromJSON(%s) should fail", in)
+		}
+	}
+}
+
+func TestJSONOutputPrefix(t *testing.T) {
+	box := NewCounterBox(WithOutputPrefix("svc."))
+	box.GetCounter("requests").IncrementBy(3)
+	box.Count("http", "method", "GET")
+	box.GetMin("latency").Set(2)
+	data, err := json.Marshal(box)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"counters":{"svc.http{method=\"GET\"}":{"labels":{"method":"GET"},"value":1},"svc.requests":3},"min":{"svc.latency":2},"max":{}}`
+	if string(data) != want {
+		t.Errorf("want: %s, got %s", want, data)
+	}
+	if _, ok := box.PeekCounter("svc.requests"); ok {
+		t.Error("prefixed name should not be a counter")
+	}
+
+	restored, err := FromJSON(strings.NewReader(string(data)), WithOutputPrefix("svc."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored.Snapshot(), box.Snapshot()) {
+		t.Errorf("want: %v, got %v", box.Snapshot(), restored.Snapshot())
+	}
+}
+
+// varRuns makes names of vars published by TestVar unique, expvar panics
+// on reuse of a name when the test runs repeatedly.
+var varRuns int
+
+func TestVar(t *testing.T) {
+	box := NewCounterBox()
+	varRuns++
+	name := fmt.Sprintf("counters_test_box_%d", varRuns)
+	expvar.Publish(name, box.Var())
+	box.GetCounter("hits").IncrementBy(3)
+	box.GetMax("size").Set(7)
+
+	var got struct {
+		Counters map[string]int64 `json:"counters"`
+		Max      map[string]int64 `json:"max"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Counters["hits"] != 3 || got.Max["size"] != 7 {
+		t.Errorf("want: hits 3 and size 7, got %+v", got)
+	}
+}
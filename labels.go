@@ -0,0 +1,67 @@
+package counters
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Label is a name and a value describing a dimension of a counter.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// labeledName describes a counter created with labels.
+type labeledName struct {
+	base   string
+	labels []Label
+}
+
+// canonicalName returns a name of a counter with given labels,
+// e.g. requests{method="GET",status="200"}. Labels are sorted by name,
+// so the order of arguments doesn't matter.
+func canonicalName(name string, labels []Label) string {
+	if len(labels) == 0 {
+		return name
+	}
+	b := &strings.Builder{}
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l.Name)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(l.Value))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// parseLabels turns a list of label names and values into sorted labels.
+func parseLabels(kv []string) []Label {
+	if len(kv)%2 != 0 {
+		panic("counters: labels need to be given as name and value pairs")
+	}
+	labels := make([]Label, 0, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		labels = append(labels, Label{kv[i], kv[i+1]})
+	}
+	sort.SliceStable(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels
+}
+
+// Count increments a counter identified by name and labels given as
+// name, value pairs, e.g. Count("requests", "method", "GET", "status", "200").
+// Labels are sorted by name, so the same set of labels always refer to
+// the same counter. It panics if the number of label arguments is odd.
+func (c *CounterBox) Count(name string, labels ...string) int64 {
+	ls := parseLabels(labels)
+	full := canonicalName(name, ls)
+	if len(ls) > 0 {
+		c.labels.LoadOrStore(full, &labeledName{name, ls})
+	}
+	return c.GetCounter(full).Increment()
+}
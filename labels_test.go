@@ -0,0 +1,30 @@
+package counters
+
+import "testing"
+
+func TestCount(t *testing.T) {
+	box := NewCounterBox()
+	box.Count("requests", "method", "GET", "status", "200")
+	box.Count("requests", "status", "200", "method", "GET")
+	box.Count("requests", "method", "POST", "status", "200")
+	box.Count("requests")
+
+	for name, want := range map[string]int64{
+		`requests{method="GET",status="200"}`:  2,
+		`requests{method="POST",status="200"}`: 1,
+		`requests`:                             1,
+	} {
+		if v, ok := box.PeekCounter(name); !ok || v != want {
+			t.Errorf("%s, want: %d, got %d", name, want, v)
+		}
+	}
+}
+
+func TestCountOddLabels(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Count should panic on odd number of label arguments")
+		}
+	}()
+	NewCounterBox().Count("requests", "method")
+}
@@ -0,0 +1,73 @@
+package counters
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultMarkExpiry is how long a mark waits for Measure unless set by
+// WithMarkExpiry.
+const DefaultMarkExpiry = 10 * time.Minute
+
+// markSet keeps times of marks set by Mark.
+type markSet struct {
+	mu        sync.Mutex
+	marks     map[string]time.Time
+	lastSweep time.Time
+}
+
+// set remembers key marked at now. Marks older than expiry are removed at
+// most once per expiry.
+func (s *markSet) set(key string, now time.Time, expiry time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.marks == nil {
+		s.marks = make(map[string]time.Time)
+	}
+	if now.Sub(s.lastSweep) >= expiry {
+		for k, t := range s.marks {
+			if now.Sub(t) >= expiry {
+				delete(s.marks, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	s.marks[key] = now
+}
+
+// take removes key and returns the time it was marked at, ok is false if
+// there is no mark of key or it expired.
+func (s *markSet) take(key string, now time.Time, expiry time.Duration) (t time.Time, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok = s.marks[key]
+	delete(s.marks, key)
+	return t, ok && now.Sub(t) < expiry
+}
+
+func (c *CounterBox) markExpiryOrDefault() time.Duration {
+	if c.markExpiry > 0 {
+		return c.markExpiry
+	}
+	return DefaultMarkExpiry
+}
+
+// Mark remembers the current time of the box clock under key, e.g.
+// a request ID, for a later Measure. Marking a key again moves its mark.
+// Marks not measured within the mark expiry, see WithMarkExpiry, are dropped.
+func (c *CounterBox) Mark(key string) {
+	c.marks.set(key, c.clock.Now(), c.markExpiryOrDefault())
+}
+
+// Measure records the time elapsed since Mark(key) as a duration of metric,
+// in the same counters as Time, and removes the mark. It reports whether
+// there was a mark of key, nothing is recorded if it's missing or expired.
+func (c *CounterBox) Measure(key, metric string) bool {
+	now := c.clock.Now()
+	start, ok := c.marks.take(key, now, c.markExpiryOrDefault())
+	if !ok {
+		return false
+	}
+	c.observeDuration(metric, now.Sub(start))
+	return true
+}
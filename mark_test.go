@@ -0,0 +1,66 @@
+package counters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarkMeasure(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	box.Mark("req1")
+	clock.Advance(time.Second)
+	box.Mark("req2")
+	clock.Advance(3 * time.Second)
+
+	if !box.Measure("req1", "stage") {
+		t.Error("want req1 measured")
+	}
+	if !box.Measure("req2", "stage") {
+		t.Error("want req2 measured")
+	}
+	if box.Measure("req1", "stage") {
+		t.Error("want req1 removed after measure")
+	}
+	if box.Measure("missing", "stage") {
+		t.Error("want missing key not measured")
+	}
+
+	tests := []struct {
+		value namedValue
+		want  int64
+	}{
+		{box.GetCounter("stage.count"), 2},
+		{box.GetCounter("stage.total"), int64(7 * time.Second)},
+		{box.GetMax("stage"), int64(4 * time.Second)},
+		{box.GetMin("stage"), int64(3 * time.Second)},
+	}
+	for _, tt := range tests {
+		if got := tt.value.Value(); got != tt.want {
+			t.Errorf("%s, want: %d, got %d", tt.value.Name(), tt.want, got)
+		}
+	}
+}
+
+func TestMarkExpiry(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock), WithMarkExpiry(time.Minute))
+	box.Mark("old")
+	clock.Advance(2 * time.Minute)
+	if box.Measure("old", "stage") {
+		t.Error("want expired mark not measured")
+	}
+	if v := box.GetCounter("stage.count").Value(); v != 0 {
+		t.Errorf("want nothing recorded for expired mark, got %d", v)
+	}
+
+	box.Mark("a")
+	clock.Advance(2 * time.Minute)
+	box.Mark("b")
+	box.marks.mu.Lock()
+	n := len(box.marks.marks)
+	box.marks.mu.Unlock()
+	if n != 1 {
+		t.Errorf("want stale marks swept, got %d marks", n)
+	}
+}
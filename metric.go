@@ -0,0 +1,78 @@
+package counters
+
+import (
+	"sort"
+	"sync"
+)
+
+// MetricKind tells what a metric measures.
+type MetricKind int
+
+const (
+	// KindCounter is a counter of events.
+	KindCounter MetricKind = iota
+	// KindMin is a minimal observed value.
+	KindMin
+	// KindMax is a maximal observed value.
+	KindMax
+	// KindGauge is a value which can go up and down, e.g. computed one.
+	KindGauge
+)
+
+func (k MetricKind) String() string {
+	switch k {
+	case KindCounter:
+		return "counter"
+	case KindMin:
+		return "min"
+	case KindMax:
+		return "max"
+	case KindGauge:
+		return "gauge"
+	}
+	return "unknown"
+}
+
+// Metric is a common interface of all counters in a box.
+type Metric interface {
+	// Name returns a name of metric.
+	Name() string
+	// Value returns a current value.
+	Value() int64
+	// Kind returns what the metric measures.
+	Kind() MetricKind
+}
+
+func appendMetrics(metrics []Metric, m *sync.Map) []Metric {
+	start := len(metrics)
+	m.Range(func(key interface{}, value interface{}) bool {
+		if v, ok := value.(Metric); ok {
+			metrics = append(metrics, v)
+		}
+		return true
+	})
+	part := metrics[start:]
+	sort.Slice(part, func(i, j int) bool { return part[i].Name() < part[j].Name() })
+	return metrics
+}
+
+// Metrics returns all counters, then minima and maxima counters, each
+// group sorted by name.
+func (c *CounterBox) Metrics() []Metric {
+	var metrics []Metric
+	metrics = appendMetrics(metrics, c.counters)
+	metrics = appendMetrics(metrics, c.min)
+	return appendMetrics(metrics, c.max)
+}
+
+// MetricsByKind returns metrics of kind k sorted by name, e.g. KindGauge
+// returns computed counters.
+func (c *CounterBox) MetricsByKind(k MetricKind) []Metric {
+	var metrics []Metric
+	for _, m := range c.Metrics() {
+		if m.Kind() == k {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics
+}
@@ -0,0 +1,70 @@
+package counters
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("b").Increment()
+	box.GetShardedCounter("a").Increment()
+	box.RegisterComputed("c", func(*CounterBox) int64 { return 5 })
+	box.GetMin("d").Set(1)
+	box.GetMax("d").Set(2)
+
+	want := []struct {
+		name string
+		kind MetricKind
+	}{
+		{"a", KindCounter},
+		{"b", KindCounter},
+		{"c", KindGauge},
+		{"d", KindMin},
+		{"d", KindMax},
+	}
+	metrics := box.Metrics()
+	if len(metrics) != len(want) {
+		t.Fatalf("want %d metrics, got %d", len(want), len(metrics))
+	}
+	for i, m := range metrics {
+		if m.Name() != want[i].name || m.Kind() != want[i].kind {
+			t.Errorf("metric %d, want: %s %s, got %s %s", i, want[i].name, want[i].kind, m.Name(), m.Kind())
+		}
+	}
+	if k := (NopBox{}).GetMin("x").Kind(); k != KindMin {
+		t.Errorf("nop min, want: %s, got %s", KindMin, k)
+	}
+}
+
+func TestMetricsByKind(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("b").Increment()
+	box.GetShardedCounter("a").Increment()
+	box.RegisterComputed("c", func(*CounterBox) int64 { return 5 })
+	box.GetMin("d").Set(1)
+	box.GetMin("e").Set(1)
+	box.GetMax("d").Set(2)
+
+	tests := []struct {
+		kind  MetricKind
+		names []string
+	}{
+		{KindCounter, []string{"a", "b"}},
+		{KindGauge, []string{"c"}},
+		{KindMin, []string{"d", "e"}},
+		{KindMax, []string{"d"}},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, m := range box.MetricsByKind(tt.kind) {
+			if m.Kind() != tt.kind {
+				t.Errorf("%s: want only %s, got %s %s", tt.kind, tt.kind, m.Name(), m.Kind())
+			}
+			names = append(names, m.Name())
+		}
+		if !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("%s, want: %v, got %v", tt.kind, tt.names, names)
+		}
+	}
+}
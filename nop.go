@@ -0,0 +1,69 @@
+package counters
+
+import (
+	"io"
+	"time"
+)
+
+// Box is implemented by CounterBox and NopBox, it allows to turn off
+// counting by injecting a NopBox.
+type Box interface {
+	Counters
+	PeekCounter(name string) (int64, bool)
+	PeekMin(name string) (int64, bool)
+	PeekMax(name string) (int64, bool)
+	Snapshot() CounterSnapshot
+}
+
+var (
+	_ Box = (*CounterBox)(nil)
+	_ Box = NopBox{}
+)
+
+// NopBox is a Box which doesn't count anything. All its counters are
+// shared, ignore updates and report zero.
+type NopBox struct{}
+
+type nopCounter struct{}
+
+func (nopCounter) Increment() int64                          { return 0 }
+func (nopCounter) IncrementBy(num int) int64                 { return 0 }
+func (nopCounter) Decrement() int64                          { return 0 }
+func (nopCounter) DecrementBy(num int) int64                 { return 0 }
+func (nopCounter) IncrementByDuration(d time.Duration) int64 { return 0 }
+func (nopCounter) Set(num int)                               {}
+func (nopCounter) Name() string                              { return "" }
+func (nopCounter) Value() int64                              { return 0 }
+func (nopCounter) Kind() MetricKind                          { return KindCounter }
+
+type nopMaxMin struct{}
+
+func (nopMaxMin) Set(int)                     {}
+func (nopMaxMin) SetDuration(d time.Duration) {}
+func (nopMaxMin) SetMany(values []int)        {}
+func (nopMaxMin) ResetTo(v int)               {}
+func (nopMaxMin) Name() string                { return "" }
+func (nopMaxMin) Value() int64                { return 0 }
+
+type nopMin struct{ nopMaxMin }
+
+func (nopMin) Kind() MetricKind { return KindMin }
+
+type nopMax struct{ nopMaxMin }
+
+func (nopMax) Kind() MetricKind { return KindMax }
+
+func (NopBox) Get(string) Counter                    { return nopCounter{} }
+func (NopBox) Min(string) MaxMinValue                { return nopMin{} }
+func (NopBox) Max(string) MaxMinValue                { return nopMax{} }
+func (n NopBox) WithPrefix(string) Counters          { return n }
+func (NopBox) GetCounter(string) Counter             { return nopCounter{} }
+func (NopBox) GetMin(string) MaxMinValue             { return nopMin{} }
+func (NopBox) GetMax(string) MaxMinValue             { return nopMax{} }
+func (NopBox) WriteTo(w io.Writer) (int64, error)    { return 0, nil }
+func (NopBox) Prefix() string                        { return "" }
+func (NopBox) String() string                        { return "" }
+func (NopBox) PeekCounter(name string) (int64, bool) { return 0, false }
+func (NopBox) PeekMin(name string) (int64, bool)     { return 0, false }
+func (NopBox) PeekMax(name string) (int64, bool)     { return 0, false }
+func (NopBox) Snapshot() CounterSnapshot             { return CounterSnapshot{} }
@@ -0,0 +1,30 @@
+package counters
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNopBox(t *testing.T) {
+	var box Box = NopBox{}
+	allocs := testing.AllocsPerRun(100, func() {
+		c := box.GetCounter("test")
+		c.Increment()
+		c.IncrementBy(5)
+		box.GetMax("max").Set(7)
+		box.WithPrefix("p.").GetMin("min").Set(3)
+	})
+	if allocs != 0 {
+		t.Errorf("want no allocations, got %f", allocs)
+	}
+	if v := box.GetCounter("test").Value(); v != 0 {
+		t.Errorf("want: 0, got %d", v)
+	}
+	if _, ok := box.PeekCounter("test"); ok {
+		t.Errorf("NopBox should not keep counters")
+	}
+	buf := &bytes.Buffer{}
+	if n, err := box.WriteTo(buf); n != 0 || err != nil || buf.Len() != 0 {
+		t.Errorf("WriteTo should write nothing, got %d, %v, %q", n, err, buf)
+	}
+}
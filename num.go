@@ -0,0 +1,57 @@
+package counters
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Integer is a constraint of integer types usable with NumCounter.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// NumCounter is a counter of values of an integer type T. Like the types,
+// it wraps around on overflow.
+type NumCounter[T Integer] struct {
+	name string
+	// bits keeps the value extended to 64 bits, converting it back to T
+	// truncates it, which gives the arithmetic of T.
+	bits uint64
+}
+
+// Add adds v to the counter and returns the new value.
+func (n *NumCounter[T]) Add(v T) T {
+	return T(atomic.AddUint64(&n.bits, uint64(v)))
+}
+
+// Set sets a specific value.
+func (n *NumCounter[T]) Set(v T) {
+	atomic.StoreUint64(&n.bits, uint64(v))
+}
+
+// Name returns a name of counter.
+func (n *NumCounter[T]) Name() string {
+	return n.name
+}
+
+// Value returns a current value of counter.
+func (n *NumCounter[T]) Value() T {
+	return T(atomic.LoadUint64(&n.bits))
+}
+
+// GetNum returns a counter of type T and given name from box, if doesn't
+// exist than create. These counters are kept apart from other counters of
+// the box. It panics if a counter of the name exists with another type.
+func GetNum[T Integer](box *CounterBox, name string) *NumCounter[T] {
+	name = box.checkName(name)
+	value, ok := box.nums.Load(name)
+	if !ok {
+		value, _ = box.nums.LoadOrStore(name, &NumCounter[T]{name: name})
+	}
+	v, ok := value.(*NumCounter[T])
+	if !ok {
+		panic(fmt.Sprintf("counters: counter %q has type %T", name, value))
+	}
+	return v
+}
@@ -0,0 +1,57 @@
+package counters
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestNumCounterInt32(t *testing.T) {
+	box := NewCounterBox()
+	c := GetNum[int32](box, "small")
+	c.Add(math.MaxInt32 - 1)
+	if v := GetNum[int32](box, "small").Add(1); v != math.MaxInt32 {
+		t.Errorf("want: %d, got %d", math.MaxInt32, v)
+	}
+	if v := c.Add(1); v != math.MinInt32 {
+		t.Errorf("want wrap around to %d, got %d", math.MinInt32, v)
+	}
+	if v := c.Add(-5); v != math.MaxInt32-4 {
+		t.Errorf("want: %d, got %d", math.MaxInt32-4, v)
+	}
+}
+
+func TestNumCounterUint64(t *testing.T) {
+	box := NewCounterBox()
+	c := GetNum[uint64](box, "big")
+	var wg sync.WaitGroup
+	for x := 0; x < 10; x++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := 0; y < 100; y++ {
+				c.Add(1 << 60)
+			}
+		}()
+	}
+	wg.Wait()
+	// 1000 * 2^60 wraps around to 1000 mod 16 * 2^60.
+	if want := uint64(8) << 60; c.Value() != want {
+		t.Errorf("want: %d, got %d", want, c.Value())
+	}
+	c.Set(math.MaxUint64)
+	if v := c.Value(); v != math.MaxUint64 {
+		t.Errorf("want: %d, got %d", uint64(math.MaxUint64), v)
+	}
+}
+
+func TestGetNumTypeMismatch(t *testing.T) {
+	box := NewCounterBox()
+	GetNum[int32](box, "x")
+	defer func() {
+		if recover() == nil {
+			t.Errorf("GetNum should panic on type mismatch")
+		}
+	}()
+	GetNum[uint64](box, "x")
+}
@@ -0,0 +1,46 @@
+package counters
+
+import "math"
+
+// observation keeps all values updated by RecordObservation of a name, so
+// they are looked up once.
+type observation struct {
+	sum       FloatCounter
+	count     Counter
+	max       FloatMaxMin
+	min       FloatMaxMin
+	histogram *Histogram
+}
+
+// defaultObservationBounds are bounds of histograms created by
+// RecordObservation when no bounds are given.
+var defaultObservationBounds = []int64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000}
+
+// RecordObservation records v in all values describing a metric of given
+// name: the float counter name.sum, the counter name.count, the float max
+// and min values of name and the histogram of name, which gets v rounded to
+// an integer. If the histogram doesn't exist, it's created with given
+// bounds, or with bounds 1, 2, 5, 10, 20, ..., 5000, 10000 if there are
+// none, bounds of an existing one are kept, see GetHistogram.
+func (c *CounterBox) RecordObservation(name string, v float64, bounds ...int64) {
+	name = c.checkName(name)
+	value, ok := c.observations.Load(name)
+	if !ok {
+		if len(bounds) == 0 {
+			bounds = defaultObservationBounds
+		}
+		value, _ = c.observations.LoadOrStore(name, &observation{
+			sum:       c.GetFloatCounter(name + ".sum"),
+			count:     c.GetCounter(name + ".count"),
+			max:       c.GetFloatMax(name),
+			min:       c.GetFloatMin(name),
+			histogram: c.GetHistogram(name, bounds...),
+		})
+	}
+	o := value.(*observation)
+	o.sum.Add(v)
+	o.count.Increment()
+	o.max.Set(v)
+	o.min.Set(v)
+	o.histogram.Observe(int64(math.Round(v)))
+}
@@ -0,0 +1,59 @@
+package counters
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecordObservation(t *testing.T) {
+	box := NewCounterBox()
+	box.GetHistogram("latency", 10, 100)
+	for _, v := range []float64{2.5, 40, 7.25, 250} {
+		box.RecordObservation("latency", v)
+	}
+
+	if v := box.GetFloatCounter("latency.sum").Value(); v != 299.75 {
+		t.Errorf("sum, want: 299.75, got %f", v)
+	}
+	if v := box.GetCounter("latency.count").Value(); v != 4 {
+		t.Errorf("count, want: 4, got %d", v)
+	}
+	if v := box.GetFloatMax("latency").Value(); v != 250 {
+		t.Errorf("max, want: 250, got %f", v)
+	}
+	if v := box.GetFloatMin("latency").Value(); v != 2.5 {
+		t.Errorf("min, want: 2.5, got %f", v)
+	}
+	h := box.GetHistogram("latency")
+	if want, got := []int64{2, 1, 1}, h.Counts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("histogram counts, want: %v, got %v", want, got)
+	}
+	// 2.5 and 7.25 are rounded to 3 and 7.
+	if v := h.Sum(); v != 300 {
+		t.Errorf("histogram sum, want: 300, got %d", v)
+	}
+}
+
+func TestRecordObservationBounds(t *testing.T) {
+	box := NewCounterBox()
+	for _, v := range []float64{0.5, 3, 120, 20000} {
+		box.RecordObservation("default", v)
+		box.RecordObservation("given", v, 10, 1000)
+	}
+	h := box.GetHistogram("default")
+	if got := h.Bounds(); !reflect.DeepEqual(got, defaultObservationBounds) {
+		t.Errorf("default bounds, want: %v, got %v", defaultObservationBounds, got)
+	}
+	if want, got := []int64{1, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 1}, h.Counts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("default counts, want: %v, got %v", want, got)
+	}
+	h = box.GetHistogram("given")
+	if want, got := []int64{2, 1, 1}, h.Counts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("given counts, want: %v, got %v", want, got)
+	}
+	// Bounds of an existing histogram are kept.
+	box.RecordObservation("given", 5, 1, 2, 3)
+	if want, got := []int64{10, 1000}, h.Bounds(); !reflect.DeepEqual(got, want) {
+		t.Errorf("given bounds, want: %v, got %v", want, got)
+	}
+}
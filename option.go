@@ -0,0 +1,180 @@
+package counters
+
+import (
+	"sort"
+	"time"
+)
+
+// Option configures a CounterBox created by NewCounterBox.
+type Option func(*CounterBox)
+
+// WithClock sets a clock used by the box, by default the time package
+// is used.
+func WithClock(clock Clock) Option {
+	return func(c *CounterBox) {
+		c.clock = clock
+	}
+}
+
+// WithLabel sets a label which WriteTo prints as a header, it helps to tell
+// apart dumps of several boxes.
+func WithLabel(label string) Option {
+	return func(c *CounterBox) {
+		c.label = label
+	}
+}
+
+// WithMaxOutputBytes limits the output of WriteTo and the like to about n
+// bytes. A longer output is cut after the last full line which fits in n
+// bytes and followed by a line "... (truncated, N more bytes)" telling how
+// many bytes were omitted. The whole output is rendered in memory first.
+func WithMaxOutputBytes(n int) Option {
+	return func(c *CounterBox) {
+		c.maxOutputBytes = n
+	}
+}
+
+// WithOutputPrefix makes WriteTo, the JSON and the Prometheus output print
+// names of counters prefixed with prefix, e.g. a name of the service.
+// The counters are still kept and looked up under their original names.
+func WithOutputPrefix(prefix string) Option {
+	return func(c *CounterBox) {
+		c.outputPrefix = prefix
+	}
+}
+
+// WithHumanReadable makes WriteTo print values of counters, min and max
+// values with SI suffixes and one decimal digit, e.g. 12034567 as 12.0M.
+// Values between -1000 and 1000 are printed as they are. JSON, Prometheus
+// and other machine readable outputs keep exact values.
+func WithHumanReadable() Option {
+	return func(c *CounterBox) {
+		c.humanReadable = true
+	}
+}
+
+// WithConstLabels sets labels, e.g. service="api", of every metric in the
+// Prometheus, InfluxDB and JSON outputs. They are merged with tags set by
+// SetTags, a const label wins over a tag of the same name. The map is copied.
+func WithConstLabels(labels map[string]string) Option {
+	return func(c *CounterBox) {
+		c.constLabels = copyTags(labels)
+	}
+}
+
+// WithAlignedOutput makes WriteTo pad values with spaces, so values of all
+// sections end in the same column, e.g.
+//
+//	== Counters ==
+//	  a:       1
+//	  longer: 20
+//
+// JSON, Prometheus and other machine readable outputs are unchanged.
+func WithAlignedOutput() Option {
+	return func(c *CounterBox) {
+		c.alignedOutput = true
+	}
+}
+
+// WithRenderTime makes WriteTo print a footer with the time of rendering
+// taken from the box clock.
+func WithRenderTime() Option {
+	return func(c *CounterBox) {
+		c.renderTime = true
+	}
+}
+
+// WithMaxNameLen limits the length of counter names to n bytes. Longer
+// names passed to GetCounter, GetMin, GetMax and the like are truncated,
+// so all names sharing the first n bytes refer to the same counter.
+func WithMaxNameLen(n int) Option {
+	return func(c *CounterBox) {
+		c.maxNameLen = n
+	}
+}
+
+// WithCreationTrace makes GetCounter record file:line of the code outside
+// the package which created a counter, also through helpers like Count or
+// DeclareCounter, Info returns it as Source. It helps to find code
+// creating unexpected counters, it's off by default because it makes
+// creation of counters slower.
+func WithCreationTrace() Option {
+	return func(c *CounterBox) {
+		c.creationTrace = true
+	}
+}
+
+// WithUpdateTimes makes counters record the time of every update, Info
+// returns it as UpdatedAt. It's off by default because it reads the clock
+// on every update, which makes updates several times slower.
+func WithUpdateTimes() Option {
+	return func(c *CounterBox) {
+		c.updateTimes = true
+	}
+}
+
+// WithUnsafeSingleThreaded makes GetCounter create counters updated with
+// plain additions instead of atomic operations, which is faster in batch
+// jobs running in a single goroutine. Such a box must not be used
+// concurrently, including reading it, e.g. by an HTTP handler, while it's
+// updated. Built with the race detector, concurrent updates of a counter
+// panic. The counters don't record the time of updates, so Info doesn't
+// find them, and they can't be disabled. Min and max values are unchanged.
+func WithUnsafeSingleThreaded() Option {
+	return func(c *CounterBox) {
+		c.singleThreaded = true
+	}
+}
+
+// WithStrictNames makes names of counters and min or max values unique.
+// Getting a min or a max value under a name already used by a counter,
+// e.g. GetMax("x") after GetCounter("x"), or the other way round panics.
+// A min and a max value may still share a name, as Time records them.
+// By default a counter, a min and a max value can share a name.
+func WithStrictNames() Option {
+	return func(c *CounterBox) {
+		c.strictNames = true
+	}
+}
+
+// WithErrorTypes sets errors recognized by CountErrorByType by names under
+// which they are counted, e.g. {"timeout": context.DeadlineExceeded}.
+func WithErrorTypes(types map[string]error) Option {
+	return func(c *CounterBox) {
+		c.errorTypes = c.errorTypes[:0]
+		for name, err := range types {
+			c.errorTypes = append(c.errorTypes, errorType{name, err})
+		}
+		sort.Slice(c.errorTypes, func(i, j int) bool { return c.errorTypes[i].name < c.errorTypes[j].name })
+	}
+}
+
+// WithMarkExpiry sets how long a mark set by Mark waits for Measure, by
+// default DefaultMarkExpiry. Expired marks are dropped, so events which never
+// reach Measure don't keep memory.
+func WithMarkExpiry(d time.Duration) Option {
+	return func(c *CounterBox) {
+		c.markExpiry = d
+	}
+}
+
+// WithShardedHistograms makes histograms of the box spread observations
+// over several shards, which are summed when the histogram is read. It
+// lowers contention when many goroutines observe values of one histogram
+// at the cost of memory and slower reads.
+func WithShardedHistograms() Option {
+	return func(c *CounterBox) {
+		c.shardedHistograms = true
+	}
+}
+
+// WithTimerUnit sets a unit of durations recorded by Time, TimeErr and other
+// timer helpers, by default time.Nanosecond. Durations are truncated to whole
+// units, e.g. with time.Millisecond 2.5ms is recorded as 2. Changing the unit
+// of a running program changes magnitudes of the recorded values, so values
+// recorded before and after the change must not be compared.
+func WithTimerUnit(unit time.Duration) Option {
+	return func(c *CounterBox) {
+		c.timerUnit = unit
+	}
+}
@@ -0,0 +1,125 @@
+package counters
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Types of Prometheus metrics which can be used for min and max values.
+const (
+	PrometheusGauge   = "gauge"
+	PrometheusCounter = "counter"
+)
+
+type prometheusOptions struct {
+	minMaxType string
+	noMetadata bool
+}
+
+// PrometheusOption configures the Prometheus output.
+type PrometheusOption func(*prometheusOptions)
+
+// WithMinMaxType sets the TYPE reported for min and max values, it should be
+// PrometheusGauge (default) or PrometheusCounter.
+func WithMinMaxType(typ string) PrometheusOption {
+	return func(o *prometheusOptions) {
+		o.minMaxType = typ
+	}
+}
+
+// WithoutMetadata omits HELP and TYPE lines, so only sample lines are
+// written. It makes the output of boxes with many counters much smaller,
+// but scrapers treat all metrics as untyped.
+func WithoutMetadata() PrometheusOption {
+	return func(o *prometheusOptions) {
+		o.noMetadata = true
+	}
+}
+
+func newPrometheusOptions(opts []PrometheusOption) *prometheusOptions {
+	o := &prometheusOptions{minMaxType: PrometheusGauge}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// prometheusName replaces characters not allowed in a Prometheus metric name
+// with an underscore.
+func prometheusName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == ':':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// prometheusLabels returns tags formatted as a Prometheus label set,
+// e.g. {env="prod"}, or an empty string if there are no tags.
+func prometheusLabels(tags []Label) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	b := &strings.Builder{}
+	b.WriteByte('{')
+	for i, t := range tags {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(b, "%s=\"%s\"", prometheusName(t.Name), labelEscaper.Replace(t.Value))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// formatSample formats a sample value, integers are printed without
+// an exponent.
+func formatSample(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// WritePrometheus writes all counters in the Prometheus text format, see
+// MetricFamilies. Counters are exported under their names prefixed with the
+// prefix set by WithOutputPrefix, min and max values, also float ones, get
+// _min and _max suffixes respectively. Float minima which were never set
+// are omitted. Counters which were reset get a _reset_total counter with
+// their reset epoch, see ResetEpoch. Counters created by Count are samples
+// of a family of their base name with their labels. Tags set by SetTags and
+// labels set by WithConstLabels are labels of the metrics.
+func (c *CounterBox) WritePrometheus(w io.Writer, opts ...PrometheusOption) error {
+	o := newPrometheusOptions(opts)
+	buf := &bytes.Buffer{}
+	for _, f := range c.metricFamilies(o.minMaxType, true) {
+		if !o.noMetadata {
+			fmt.Fprintf(buf, "# HELP %s %s\n", f.Name, helpEscaper.Replace(f.Help))
+			fmt.Fprintf(buf, "# TYPE %s %s\n", f.Name, f.Type)
+		}
+		for _, s := range f.Samples {
+			fmt.Fprintf(buf, "%s%s %s\n", f.Name, prometheusLabels(s.Labels), formatSample(s.Value))
+		}
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// CreatePrometheusHandler creates a handler printing values of all counters
+// in the Prometheus text format.
+func (c *CounterBox) CreatePrometheusHandler(opts ...PrometheusOption) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		c.WritePrometheus(w, opts...)
+	}
+}
@@ -0,0 +1,159 @@
+package counters
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreatePrometheusHandler(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("requests.total").IncrementBy(3)
+	box.GetMin("latency").Set(4)
+	box.GetMax("latency").Set(9)
+
+	rec := httptest.NewRecorder()
+	box.CreatePrometheusHandler()(rec, httptest.NewRequest("GET", "/metrics", nil))
+	want := `# HELP requests_total Counter requests.total.
+# TYPE requests_total counter
+requests_total 3
+# HELP latency_min Min value of latency.
+# TYPE latency_min gauge
+latency_min 4
+# HELP latency_max Max value of latency.
+# TYPE latency_max gauge
+latency_max 9
+`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestPrometheusMinMaxType(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("requests").Increment()
+	box.GetMin("a").Set(1)
+	box.GetMin("b").Set(2)
+	box.GetMax("a").Set(3)
+
+	for _, typ := range []string{PrometheusGauge, PrometheusCounter} {
+		rec := httptest.NewRecorder()
+		box.CreatePrometheusHandler(WithMinMaxType(typ))(rec, httptest.NewRequest("GET", "/metrics", nil))
+		for _, line := range strings.Split(rec.Body.String(), "\n") {
+			if !strings.HasPrefix(line, "# TYPE ") {
+				continue
+			}
+			fields := strings.Fields(line)
+			want := typ
+			if fields[2] == "requests" {
+				want = PrometheusCounter
+			}
+			if fields[3] != want {
+				t.Errorf("option %s: %q, want type %s", typ, line, want)
+			}
+		}
+	}
+}
+
+func TestPrometheusName(t *testing.T) {
+	for in, want := range map[string]string{
+		"abc":       "abc",
+		"a.b-c":     "a_b_c",
+		"9lives":    "_lives",
+		"ns:name_1": "ns:name_1",
+	} {
+		if got := prometheusName(in); got != want {
+			t.Errorf("prometheusName(%q), want: %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestPrometheusOutputPrefix(t *testing.T) {
+	box := NewCounterBox(WithOutputPrefix("svc."))
+	box.GetCounter("requests").IncrementBy(3)
+	box.GetMax("latency").Set(7)
+	var b strings.Builder
+	if err := box.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP svc_requests Counter svc.requests.
+# TYPE svc_requests counter
+svc_requests 3
+# HELP svc_latency_max Max value of svc.latency.
+# TYPE svc_latency_max gauge
+svc_latency_max 7
+`
+	if got := b.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+	if _, ok := box.PeekCounter("svc.requests"); ok {
+		t.Error("prefixed name should not be a counter")
+	}
+	if v, ok := box.PeekCounter("requests"); !ok || v != 3 {
+		t.Errorf("want: 3, got %d", v)
+	}
+}
+
+func TestPrometheusResetTotal(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("requests").IncrementBy(3)
+	box.GetCounter("errors").Increment()
+	var b strings.Builder
+	box.WritePrometheus(&b)
+	if strings.Contains(b.String(), "_reset_total") {
+		t.Errorf("want no reset totals before a reset, got:\n%s", b.String())
+	}
+
+	box.ResetPrefix("req")
+	box.GetCounter("requests").Increment()
+	box.SnapshotAndReset()
+	if e := box.ResetEpoch("requests"); e != 2 {
+		t.Errorf("want requests epoch: 2, got %d", e)
+	}
+	if e := box.ResetEpoch("missing"); e != 0 {
+		t.Errorf("want missing epoch: 0, got %d", e)
+	}
+	b.Reset()
+	box.WritePrometheus(&b)
+	for _, want := range []string{
+		"# TYPE requests_reset_total counter\nrequests_reset_total 2\n",
+		"# TYPE errors_reset_total counter\nerrors_reset_total 1\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("want %q in:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestPrometheusWithoutMetadata(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("requests.total").IncrementBy(3)
+	box.GetMin("latency").Set(4)
+	box.GetMax("latency").Set(9)
+
+	rec := httptest.NewRecorder()
+	box.CreatePrometheusHandler(WithoutMetadata())(rec, httptest.NewRequest("GET", "/metrics", nil))
+	want := "requests_total 3\nlatency_min 4\nlatency_max 9\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestPrometheusLabeled(t *testing.T) {
+	box := NewCounterBox(WithConstLabels(map[string]string{"service": "x"}))
+	box.Count("req", "method", "GET")
+	box.Count("req", "method", "GET")
+	box.Count("req", "method", "POST")
+	var b strings.Builder
+	if err := box.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP req Counter req.
+# TYPE req counter
+req{method="GET",service="x"} 2
+req{method="POST",service="x"} 1
+`
+	if got := b.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
@@ -0,0 +1,102 @@
+package counters
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Pusher sends values of a box to an external system, e.g. a StatsD agent.
+type Pusher interface {
+	// Push sends the current values.
+	Push() error
+}
+
+// PusherFunc is a function used as a Pusher.
+type PusherFunc func() error
+
+// Push calls f.
+func (f PusherFunc) Push() error {
+	return f()
+}
+
+// pusherEntry is a pusher registered in a box.
+type pusherEntry struct {
+	// mu makes pushes of the timer and of Flush never overlap.
+	mu     sync.Mutex
+	p      Pusher
+	once   sync.Once
+	stop   func()
+	onStop func()
+}
+
+func (e *pusherEntry) push() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.p.Push()
+}
+
+// StartPusher registers p and calls its Push every d, errors of scheduled
+// pushes are ignored. Registered pushers are also called by Flush and
+// Close. It returns a function which stops pushing and unregisters p.
+func (c *CounterBox) StartPusher(p Pusher, d time.Duration) (stop func()) {
+	return c.startPusher(p, d, nil)
+}
+
+// startPusher works like StartPusher, onStop is called once after the
+// pusher is stopped.
+func (c *CounterBox) startPusher(p Pusher, d time.Duration, onStop func()) (stop func()) {
+	e := &pusherEntry{p: p, onStop: onStop}
+	c.pushersMu.Lock()
+	c.pushers = append(c.pushers, e)
+	c.pushersMu.Unlock()
+	stopTicks := c.every(d, func() { e.push() })
+	e.stop = func() {
+		e.once.Do(func() {
+			stopTicks()
+			c.pushersMu.Lock()
+			for i, r := range c.pushers {
+				if r == e {
+					c.pushers = append(c.pushers[:i:i], c.pushers[i+1:]...)
+					break
+				}
+			}
+			c.pushersMu.Unlock()
+			if e.onStop != nil {
+				e.onStop()
+			}
+		})
+	}
+	return e.stop
+}
+
+// registeredPushers returns a copy of the registered pushers.
+func (c *CounterBox) registeredPushers() []*pusherEntry {
+	c.pushersMu.Lock()
+	defer c.pushersMu.Unlock()
+	return append([]*pusherEntry(nil), c.pushers...)
+}
+
+// Flush calls Push of all registered pushers, see StartPusher and
+// StartDogStatsD, one after another and waits until they finish, e.g. to
+// send values right before shutdown. It returns errors of all failed pushes
+// joined.
+func (c *CounterBox) Flush() error {
+	var errs []error
+	for _, e := range c.registeredPushers() {
+		if err := e.push(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes all registered pushers once and stops them. It returns
+// the error of Flush.
+func (c *CounterBox) Close() error {
+	err := c.Flush()
+	for _, e := range c.registeredPushers() {
+		e.stop()
+	}
+	return err
+}
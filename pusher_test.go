@@ -0,0 +1,77 @@
+package counters
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePusher records values of a counter at every push.
+type fakePusher struct {
+	box *CounterBox
+	err error
+
+	mu     sync.Mutex
+	pushed []int64
+}
+
+func (p *fakePusher) Push() error {
+	v, _ := p.box.PeekCounter("test")
+	p.mu.Lock()
+	p.pushed = append(p.pushed, v)
+	p.mu.Unlock()
+	return p.err
+}
+
+func (p *fakePusher) values() []int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]int64(nil), p.pushed...)
+}
+
+func TestFlush(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	p := &fakePusher{box: box}
+	stop := box.StartPusher(p, time.Minute)
+	defer stop()
+
+	box.GetCounter("test").Increment()
+	clock.Advance(time.Minute)
+	waitFor(t, func() bool { return len(p.values()) == 1 })
+
+	box.GetCounter("test").Increment()
+	if err := box.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.values(); len(got) != 2 || got[1] != 2 {
+		t.Errorf("want an immediate push of 2, got %v", got)
+	}
+
+	failing := &fakePusher{box: box, err: errors.New("failed")}
+	defer box.StartPusher(failing, time.Minute)()
+	if err := box.Flush(); err == nil || err.Error() != "failed" {
+		t.Errorf("want error of the failing pusher, got %v", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	p := &fakePusher{box: box}
+	box.StartPusher(p, time.Minute)
+	box.GetCounter("test").IncrementBy(5)
+
+	if err := box.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.values(); len(got) != 1 || got[0] != 5 {
+		t.Errorf("want a single push of 5 on close, got %v", got)
+	}
+	clock.Advance(time.Minute)
+	box.Flush()
+	if got := p.values(); len(got) != 1 {
+		t.Errorf("want no pushes after close, got %v", got)
+	}
+}
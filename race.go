@@ -0,0 +1,6 @@
+//go:build race
+
+package counters
+
+// raceEnabled is true if the package is built with the race detector.
+const raceEnabled = true
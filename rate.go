@@ -0,0 +1,68 @@
+package counters
+
+import (
+	"sync"
+	"time"
+)
+
+// RateCounter is a counter which measures how fast it grows.
+type RateCounter interface {
+	Counter
+	// Rate returns the average increase per second since the previous
+	// call of Rate, or since the counter was created.
+	Rate() float64
+	// PeakRate returns the highest rate returned by Rate so far.
+	PeakRate() float64
+}
+
+type rateCounter struct {
+	Counter
+	clock Clock
+
+	mu       sync.Mutex
+	last     int64
+	lastTime time.Time
+	peak     float64
+}
+
+func (r *rateCounter) Rate() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now, v := r.clock.Now(), r.Value()
+	elapsed := now.Sub(r.lastTime)
+	if elapsed <= 0 {
+		return 0
+	}
+	rate := float64(v-r.last) / elapsed.Seconds()
+	r.last, r.lastTime = v, now
+	if rate > r.peak {
+		r.peak = rate
+	}
+	return rate
+}
+
+func (r *rateCounter) PeakRate() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.peak
+}
+
+// GetRateCounter returns a rate counter of given name, if doesn't exist than
+// create. It counts into the counter of the same name, see GetCounter, and
+// measures the time with the box clock. To track the busiest second call
+// Rate every second, PeakRate then returns the highest one.
+func (c *CounterBox) GetRateCounter(name string) RateCounter {
+	name = c.checkName(name)
+	value, ok := c.rates.Load(name)
+	if !ok {
+		cnt := c.GetCounter(name)
+		value, _ = c.rates.LoadOrStore(name, &rateCounter{
+			Counter:  cnt,
+			clock:    c.clock,
+			last:     cnt.Value(),
+			lastTime: c.clock.Now(),
+		})
+	}
+	v, _ := value.(RateCounter)
+	return v
+}
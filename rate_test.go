@@ -0,0 +1,44 @@
+package counters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateCounter(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	cnt := box.GetRateCounter("requests")
+
+	for _, tt := range []struct {
+		n    int
+		rate float64
+		peak float64
+	}{
+		{10, 10, 10},
+		{50, 50, 50},
+		{5, 5, 50},
+		{0, 0, 50},
+	} {
+		cnt.IncrementBy(tt.n)
+		clock.Advance(time.Second)
+		if r := cnt.Rate(); r != tt.rate {
+			t.Errorf("after %d increments, want rate: %f, got %f", tt.n, tt.rate, r)
+		}
+		if p := cnt.PeakRate(); p != tt.peak {
+			t.Errorf("after %d increments, want peak: %f, got %f", tt.n, tt.peak, p)
+		}
+	}
+
+	cnt.IncrementBy(120)
+	clock.Advance(2 * time.Second)
+	if r := cnt.Rate(); r != 60 {
+		t.Errorf("over 2 seconds, want rate: 60, got %f", r)
+	}
+	if p := box.GetRateCounter("requests").PeakRate(); p != 60 {
+		t.Errorf("want peak: 60, got %f", p)
+	}
+	if v := box.GetCounter("requests").Value(); v != 185 {
+		t.Errorf("want total: 185, got %d", v)
+	}
+}
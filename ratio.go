@@ -0,0 +1,93 @@
+package counters
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// Ratio counts successes and failures and reports the ratio of failures.
+type Ratio interface {
+	// Success records a successful event.
+	Success()
+	// Failure records a failed event.
+	Failure()
+	// Ratio returns failures/(successes+failures), or 0 if nothing was
+	// recorded.
+	Ratio() float64
+	// Name returns a name of ratio.
+	Name() string
+}
+
+type ratioImpl struct {
+	name      string
+	successes int64
+	failures  int64
+}
+
+func (r *ratioImpl) Success() {
+	atomic.AddInt64(&r.successes, 1)
+}
+
+func (r *ratioImpl) Failure() {
+	atomic.AddInt64(&r.failures, 1)
+}
+
+func (r *ratioImpl) Ratio() float64 {
+	f := atomic.LoadInt64(&r.failures)
+	total := f + atomic.LoadInt64(&r.successes)
+	if total == 0 {
+		return 0
+	}
+	return float64(f) / float64(total)
+}
+
+func (r *ratioImpl) Name() string {
+	return r.name
+}
+
+// GetRatio returns a ratio of given name, if doesn't exist than create.
+// Ratios are printed by WriteTo in a separate section.
+func (c *CounterBox) GetRatio(name string) Ratio {
+	name = c.checkName(name)
+	value, ok := c.ratios.Load(name)
+	if !ok {
+		value, _ = c.ratios.LoadOrStore(name, &ratioImpl{name: name})
+	}
+	v, _ := value.(Ratio)
+	return v
+}
+
+// prefixedRatio is a Ratio printed under a prefixed name.
+type prefixedRatio struct {
+	r    Ratio
+	name string
+}
+
+func (p prefixedRatio) Success()       { p.r.Success() }
+func (p prefixedRatio) Failure()       { p.r.Failure() }
+func (p prefixedRatio) Ratio() float64 { return p.r.Ratio() }
+func (p prefixedRatio) Name() string   { return p.name }
+
+// prefixRatios returns ratios with names prefixed with prefix.
+func prefixRatios(prefix string, ratios []Ratio) []Ratio {
+	if prefix == "" {
+		return ratios
+	}
+	prefixed := make([]Ratio, len(ratios))
+	for i, r := range ratios {
+		prefixed[i] = prefixedRatio{r, prefix + r.Name()}
+	}
+	return prefixed
+}
+
+func (c *CounterBox) sortedRatios() []Ratio {
+	var ratios []Ratio
+	c.ratios.Range(func(key interface{}, value interface{}) bool {
+		if v, ok := value.(Ratio); ok {
+			ratios = append(ratios, v)
+		}
+		return true
+	})
+	sort.Slice(ratios, func(i, j int) bool { return ratios[i].Name() < ratios[j].Name() })
+	return ratios
+}
@@ -0,0 +1,26 @@
+package counters
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRatio(t *testing.T) {
+	box := NewCounterBox()
+	r := box.GetRatio("errors")
+	if v := r.Ratio(); v != 0 {
+		t.Errorf("empty ratio, want: 0, got %f", v)
+	}
+	for i := 0; i < 6; i++ {
+		r.Success()
+	}
+	for i := 0; i < 2; i++ {
+		box.GetRatio("errors").Failure()
+	}
+	if v := r.Ratio(); v != 0.25 {
+		t.Errorf("want: 0.25, got %f", v)
+	}
+	if s := box.String(); !strings.HasSuffix(s, "== Ratios ==\n  errors: 0.2500") {
+		t.Errorf("output should contain the ratio:\n%s", s)
+	}
+}
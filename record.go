@@ -0,0 +1,66 @@
+package counters
+
+import "sync"
+
+// RecordSize records a size, e.g. of a payload in bytes. It adds size to
+// counter name.total and updates max value name.max and min value name.min.
+func (c *CounterBox) RecordSize(name string, size int) {
+	c.GetCounter(name + ".total").IncrementBy(size)
+	c.GetMax(name + ".max").Set(size)
+	c.GetMin(name + ".min").Set(size)
+}
+
+// tally keeps the counters updated by Tally of a name, mu makes their
+// updates atomic for Mean.
+type tally struct {
+	mu           sync.Mutex
+	total, count Counter
+}
+
+// Tally records a batch of n items, it adds n to counter name.total and
+// increments counter name.count. Both counters are updated under a lock of
+// the name, so Mean always sees them consistent.
+func (c *CounterBox) Tally(name string, n int) {
+	t := c.getTally(name)
+	t.mu.Lock()
+	// Updates of computed counters are ignored, but they read their value.
+	if !isComputed(t.total) {
+		t.total.IncrementBy(n)
+	}
+	if !isComputed(t.count) {
+		t.count.Increment()
+	}
+	t.mu.Unlock()
+}
+
+func (c *CounterBox) getTally(name string) *tally {
+	name = c.checkName(name)
+	value, ok := c.tallies.Load(name)
+	if !ok {
+		value, _ = c.tallies.LoadOrStore(name, &tally{
+			total: c.GetCounter(name + ".total"),
+			count: c.GetCounter(name + ".count"),
+		})
+	}
+	return value.(*tally)
+}
+
+// Mean returns name.total divided by name.count, e.g. the average size of
+// batches recorded by Tally or the average duration recorded by Time.
+// It returns 0 if nothing was recorded.
+func (c *CounterBox) Mean(name string) float64 {
+	var total, count int64
+	if value, ok := c.tallies.Load(c.checkName(name)); ok {
+		t := value.(*tally)
+		t.mu.Lock()
+		total, count = t.total.Value(), t.count.Value()
+		t.mu.Unlock()
+	} else {
+		v := c.Values(name+".total", name+".count")
+		total, count = v[name+".total"], v[name+".count"]
+	}
+	if count == 0 {
+		return 0
+	}
+	return float64(total) / float64(count)
+}
@@ -0,0 +1,84 @@
+package counters
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecordSize(t *testing.T) {
+	box := NewCounterBox()
+	for _, size := range []int{512, 64, 2048, 128} {
+		box.RecordSize("payload", size)
+	}
+	if v := box.GetCounter("payload.total").Value(); v != 2752 {
+		t.Errorf("total, want: 2752, got %d", v)
+	}
+	if v := box.GetMax("payload.max").Value(); v != 2048 {
+		t.Errorf("max, want: 2048, got %d", v)
+	}
+	if v := box.GetMin("payload.min").Value(); v != 64 {
+		t.Errorf("min, want: 64, got %d", v)
+	}
+}
+
+func TestTally(t *testing.T) {
+	box := NewCounterBox()
+	if m := box.Mean("batch"); m != 0 {
+		t.Errorf("empty, want: 0, got %v", m)
+	}
+	for _, n := range []int{10, 3, 0, 7} {
+		box.Tally("batch", n)
+	}
+	if v := box.GetCounter("batch.total").Value(); v != 20 {
+		t.Errorf("total, want: 20, got %d", v)
+	}
+	if v := box.GetCounter("batch.count").Value(); v != 4 {
+		t.Errorf("count, want: 4, got %d", v)
+	}
+	if m := box.Mean("batch"); m != 5 {
+		t.Errorf("mean, want: 5, got %v", m)
+	}
+	box.Tally("batch", 1)
+	if m := box.Mean("batch"); m != 4.2 {
+		t.Errorf("mean, want: 4.2, got %v", m)
+	}
+}
+
+func TestTallyConcurrent(t *testing.T) {
+	box := NewCounterBox()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				box.Tally("batch", 3)
+			}
+		}()
+	}
+	for j := 0; j < 1000; j++ {
+		if m := box.Mean("batch"); m != 0 && m != 3 {
+			t.Fatalf("want mean 3, got %v", m)
+		}
+	}
+	wg.Wait()
+}
+
+func TestTallyWithoutBoxLock(t *testing.T) {
+	box := NewCounterBox()
+	box.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		box.Tally("batch", 3)
+		box.Mean("batch")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Tally should not wait for the box lock")
+	}
+	box.mu.Unlock()
+	<-done
+}
@@ -0,0 +1,77 @@
+package counters
+
+import (
+	"strings"
+	"sync"
+)
+
+// OnReset registers fn which is called with a name of every counter reset
+// by Group.ResetAll, ResetPrefix or SnapshotAndReset. It's called once per
+// reset counter, after the reset operation completes and outside of the box
+// lock.
+func (c *CounterBox) OnReset(fn func(name string)) {
+	c.resetMu.Lock()
+	c.onReset = append(c.onReset, fn)
+	c.resetMu.Unlock()
+}
+
+// notifyReset calls the OnReset callbacks for all names.
+func (c *CounterBox) notifyReset(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	c.resetMu.Lock()
+	callbacks := c.onReset
+	c.resetMu.Unlock()
+	for _, fn := range callbacks {
+		for _, name := range names {
+			fn(name)
+		}
+	}
+}
+
+// epocher is implemented by counters which count their resets.
+type epocher interface {
+	epoch() int64
+}
+
+// ResetEpoch returns the number of times counter name was reset by
+// Group.ResetAll, ResetPrefix, SnapshotAndReset and the like, 0 if it
+// doesn't exist or can't be reset. A scraper can tell an explicit reset
+// from a decrease of the value by a change of the epoch.
+func (c *CounterBox) ResetEpoch(name string) int64 {
+	value, ok := c.counters.Load(c.checkName(name))
+	if !ok {
+		return 0
+	}
+	if e, ok := value.(epocher); ok {
+		return e.epoch()
+	}
+	return 0
+}
+
+// ResetPrefix brings counters, min and max values which names start with
+// prefix back to their initial values. An empty prefix resets all of them.
+// The values are reset under the box lock, like Group.ResetAll.
+func (c *CounterBox) ResetPrefix(prefix string) {
+	c.mu.Lock()
+	names := resetPrefix(c.counters, prefix, nil)
+	names = resetPrefix(c.min, prefix, names)
+	names = resetPrefix(c.max, prefix, names)
+	c.mu.Unlock()
+	c.notifyReset(names)
+}
+
+// resetPrefix resets counters of m which names start with prefix and
+// appends their names to names.
+func resetPrefix(m *sync.Map, prefix string, names []string) []string {
+	m.Range(func(key interface{}, value interface{}) bool {
+		name := key.(string)
+		if r, ok := value.(resetter); ok && strings.HasPrefix(name, prefix) {
+			r.reset()
+			names = append(names, name)
+		}
+		return true
+	})
+	return names
+}
@@ -0,0 +1,82 @@
+package counters
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestOnReset(t *testing.T) {
+	box := NewCounterBox()
+	calls := map[string]int{}
+	box.OnReset(func(name string) { calls[name]++ })
+	box.GetCounter("a").Increment()
+	box.GetMax("b").Set(3)
+	box.RegisterComputed("computed", func(*CounterBox) int64 { return 1 })
+
+	box.SnapshotAndReset()
+	want := map[string]int{"a": 1, "b": 1}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("SnapshotAndReset, want: %v, got %v", want, calls)
+	}
+
+	g := box.Group("g")
+	g.GetCounter("a")
+	g.GetCounter("c")
+	g.ResetAll()
+	want = map[string]int{"a": 2, "b": 1, "c": 1}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("ResetAll, want: %v, got %v", want, calls)
+	}
+}
+
+func TestOnResetCanReadBox(t *testing.T) {
+	box := NewCounterBox()
+	var names []string
+	box.OnReset(func(name string) {
+		// Callbacks run outside of the box lock.
+		box.Snapshot()
+		names = append(names, name)
+	})
+	box.GetCounter("a")
+	box.GetMin("a")
+	box.SnapshotAndReset()
+	sort.Strings(names)
+	if want := []string{"a", "a"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("want: %v, got %v", want, names)
+	}
+}
+
+func TestResetPrefix(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("test.a").IncrementBy(3)
+	box.GetCounter("testing").IncrementBy(4)
+	box.GetCounter("other").IncrementBy(5)
+	box.GetMin("test.min").Set(1)
+	box.GetMax("test.max").Set(2)
+	box.GetMax("other.max").Set(6)
+	var reset []string
+	box.OnReset(func(name string) { reset = append(reset, name) })
+
+	box.ResetPrefix("test.")
+	want := CounterSnapshot{
+		Counters: map[string]int64{"test.a": 0, "testing": 4, "other": 5},
+		Min:      map[string]int64{"test.min": math.MaxInt64},
+		Max:      map[string]int64{"test.max": 0, "other.max": 6},
+	}
+	if got := box.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+	sort.Strings(reset)
+	if want := []string{"test.a", "test.max", "test.min"}; !reflect.DeepEqual(reset, want) {
+		t.Errorf("reset, want: %v, got %v", want, reset)
+	}
+
+	box.ResetPrefix("")
+	for name, v := range box.Snapshot().Counters {
+		if v != 0 {
+			t.Errorf("%s, want: 0, got %d", name, v)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package counters
+
+import (
+	"sync"
+	"time"
+)
+
+// StartRollup resets all counters at every boundary of period and keeps
+// totals of the last keep periods, see RollupHistory. Boundaries are
+// aligned to the box clock, e.g. with period of an hour counters are reset
+// at every full hour. Periods of a day are aligned to midnight UTC. It
+// returns a function which stops the rollup. Calling StartRollup again
+// stops the previous rollup and replaces the history.
+func (c *CounterBox) StartRollup(period time.Duration, keep int) (stop func()) {
+	if keep < 1 {
+		keep = 1
+	}
+	r := newSnapshotRing(keep)
+	done := make(chan struct{})
+	var once sync.Once
+	stop = func() { once.Do(func() { close(done) }) }
+	c.historyMu.Lock()
+	if c.rollupStop != nil {
+		c.rollupStop()
+	}
+	c.rollup, c.rollupStop = r, stop
+	c.historyMu.Unlock()
+
+	now := c.clock.Now()
+	first := c.clock.NewTicker(now.Truncate(period).Add(period).Sub(now))
+	go func() {
+		t := first
+		defer func() { t.Stop() }()
+		for rolled := false; ; {
+			select {
+			case tick := <-t.C():
+				// A tick and a stop may be ready at once, counters must
+				// not be reset after stop returned.
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if !rolled {
+					// The first tick is at the boundary, the next ones
+					// come every period.
+					t.Stop()
+					t = c.clock.NewTicker(period)
+					rolled = true
+				}
+				r.add(TimedSnapshot{tick.Truncate(period), c.SnapshotAndReset()})
+			case <-done:
+				return
+			}
+		}
+	}()
+	return stop
+}
+
+// RollupHistory returns totals of the periods ended since StartRollup was
+// called, starting from the oldest. Time of a snapshot is the end of its
+// period.
+func (c *CounterBox) RollupHistory() []TimedSnapshot {
+	c.historyMu.Lock()
+	r := c.rollup
+	c.historyMu.Unlock()
+	if r == nil {
+		return nil
+	}
+	return r.list()
+}
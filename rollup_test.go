@@ -0,0 +1,86 @@
+package counters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRollup(t *testing.T) {
+	clock := newFakeClock()
+	clock.Advance(20 * time.Minute)
+	box := NewCounterBox(WithClock(clock))
+	cnt := box.GetCounter("test")
+	stop := box.StartRollup(time.Hour, 2)
+	defer stop()
+
+	cnt.IncrementBy(5)
+	clock.Advance(40 * time.Minute)
+	waitFor(t, func() bool { return len(box.RollupHistory()) == 1 })
+	if v := cnt.Value(); v != 0 {
+		t.Errorf("want counter reset at the boundary, got %d", v)
+	}
+
+	for i := 1; i <= 3; i++ {
+		cnt.IncrementBy(i)
+		clock.Advance(time.Hour)
+		n := i + 1
+		if n > 2 {
+			n = 2
+		}
+		waitFor(t, func() bool {
+			h := box.RollupHistory()
+			return len(h) == n && h[n-1].Counters["test"] == int64(i)
+		})
+	}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := box.RollupHistory()
+	for i, s := range h {
+		if want := int64(i + 2); s.Counters["test"] != want {
+			t.Errorf("rollup %d, want: %d, got %d", i, want, s.Counters["test"])
+		}
+		if want := start.Add(time.Duration(i+3) * time.Hour); !s.Time.Equal(want) {
+			t.Errorf("rollup %d, want time %v, got %v", i, want, s.Time)
+		}
+	}
+	if v := cnt.Value(); v != 0 {
+		t.Errorf("want counter reset, got %d", v)
+	}
+}
+
+func TestRollupStop(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	cnt := box.GetCounter("test")
+	stop := box.StartRollup(time.Hour, 3)
+	clock.Advance(time.Hour)
+	waitFor(t, func() bool { return len(box.RollupHistory()) == 1 })
+	stop()
+	cnt.Increment()
+	clock.Advance(time.Hour)
+	if n := len(box.RollupHistory()); n != 1 {
+		t.Errorf("want 1 rollup after stop, got %d", n)
+	}
+	if v := cnt.Value(); v != 1 {
+		t.Errorf("want counter kept after stop, got %d", v)
+	}
+}
+
+func TestRollupRestart(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	cnt := box.GetCounter("test")
+	box.StartRollup(time.Hour, 3)
+	stop := box.StartRollup(time.Hour, 3)
+	defer stop()
+
+	for i := 1; i <= 3; i++ {
+		cnt.IncrementBy(5)
+		clock.Advance(time.Hour)
+		// A rollup still running would reset the counter before the new
+		// one, which would record 0.
+		waitFor(t, func() bool {
+			h := box.RollupHistory()
+			return len(h) == i && h[i-1].Counters["test"] == 5
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package counters
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"time"
+)
+
+// sampledCounter records only every rate-th update on average and scales
+// recorded updates by rate.
+type sampledCounter struct {
+	Counter
+	rate int
+}
+
+// sample reports whether an update should be recorded.
+func (s *sampledCounter) sample() bool {
+	return rand.IntN(s.rate) == 0
+}
+
+func (s *sampledCounter) Increment() int64 {
+	return s.IncrementBy(1)
+}
+
+func (s *sampledCounter) IncrementBy(num int) int64 {
+	if s.sample() {
+		return s.Counter.IncrementBy(num * s.rate)
+	}
+	return s.Counter.Value()
+}
+
+func (s *sampledCounter) IncrementByDuration(d time.Duration) int64 {
+	if s.sample() {
+		return s.Counter.IncrementByDuration(d * time.Duration(s.rate))
+	}
+	return s.Counter.Value()
+}
+
+func (s *sampledCounter) Decrement() int64 {
+	return s.DecrementBy(1)
+}
+
+func (s *sampledCounter) DecrementBy(num int) int64 {
+	if s.sample() {
+		return s.Counter.DecrementBy(num * s.rate)
+	}
+	return s.Counter.Value()
+}
+
+// GetSampledCounter returns a counter of given name which records on average
+// one of rate updates, multiplied by rate, so its value approximates the
+// number of all updates. It shares the value with the counter returned by
+// GetCounter. Set isn't sampled. A rate lower than 2 returns a plain counter.
+func (c *CounterBox) GetSampledCounter(name string, rate int) Counter {
+	if rate < 2 {
+		return c.GetCounter(name)
+	}
+	return &sampledCounter{c.GetCounter(name), rate}
+}
+
+// Sampled reports whether events identified by key, e.g. a request ID,
+// should be recorded when sampling one of rate keys. The decision depends
+// only on the key and the rate, so all counters and all processes sample
+// the same events. A rate lower than 2 accepts all keys.
+func (c *CounterBox) Sampled(key string, rate int) bool {
+	if rate < 2 {
+		return true
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	// FNV of similar keys differs mostly in high bits, mix them into
+	// the low ones (the finalizer of splitmix64).
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x%uint64(rate) == 0
+}
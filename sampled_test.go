@@ -0,0 +1,58 @@
+package counters
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestSampledCounter(t *testing.T) {
+	box := NewCounterBox()
+	cnt := box.GetSampledCounter("test", 10)
+	const n = 200000
+	for i := 0; i < n; i++ {
+		cnt.Increment()
+	}
+	// The standard deviation of the estimate is about sqrt(n*rate) ~ 1414.
+	if v := box.GetCounter("test").Value(); math.Abs(float64(v-n)) > 0.05*n {
+		t.Errorf("want about %d, got %d", n, v)
+	}
+	if v := box.GetCounter("test").Value() % 10; v != 0 {
+		t.Errorf("value should be a multiple of rate, got remainder %d", v)
+	}
+}
+
+func TestSampledCounterLowRate(t *testing.T) {
+	box := NewCounterBox()
+	cnt := box.GetSampledCounter("test", 1)
+	for i := 0; i < 10; i++ {
+		cnt.Increment()
+	}
+	if v := cnt.Value(); v != 10 {
+		t.Errorf("want: 10, got %d", v)
+	}
+}
+
+func TestSampled(t *testing.T) {
+	box := NewCounterBox()
+	other := NewCounterBox()
+	const n, rate = 100000, 10
+	accepted := 0
+	for i := 0; i < n; i++ {
+		key := "request-" + strconv.Itoa(i)
+		s := box.Sampled(key, rate)
+		if s != box.Sampled(key, rate) || s != other.Sampled(key, rate) {
+			t.Fatalf("want the same decision for key %s", key)
+		}
+		if s {
+			accepted++
+		}
+	}
+	// The standard deviation is about sqrt(n/rate) ~ 100.
+	if want := n / rate; math.Abs(float64(accepted-want)) > 0.05*float64(want) {
+		t.Errorf("want about %d accepted, got %d", want, accepted)
+	}
+	if !box.Sampled("any", 1) {
+		t.Error("rate 1 should accept all keys")
+	}
+}
@@ -0,0 +1,133 @@
+package counters
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shard is a part of a sharded counter, padded so shards don't share
+// a cache line.
+type shard struct {
+	value int64
+	_     [56]byte
+}
+
+// shardedCounter spreads updates over several shards to avoid contention
+// on a single cache line, its value is the sum of all shards.
+type shardedCounter struct {
+	name   string
+	shards []shard
+	mask   uint32
+	// resetEpoch is the number of resets, see ResetEpoch.
+	resetEpoch int64
+	// updateMu serializes updates with a function, see update.
+	updateMu sync.Mutex
+}
+
+// numShards returns the number of shards of sharded values, a power of two
+// not smaller than GOMAXPROCS.
+func numShards() int {
+	n := 1
+	for n < runtime.GOMAXPROCS(0) {
+		n <<= 1
+	}
+	return n
+}
+
+func newShardedCounter(name string) *shardedCounter {
+	n := numShards()
+	return &shardedCounter{name: name, shards: make([]shard, n), mask: uint32(n - 1)}
+}
+
+func (s *shardedCounter) add(num int64) int64 {
+	return atomic.AddInt64(&s.shards[rand.Uint32()&s.mask].value, num)
+}
+
+func (s *shardedCounter) Increment() int64 {
+	return s.add(1)
+}
+
+func (s *shardedCounter) IncrementBy(num int) int64 {
+	return s.add(int64(num))
+}
+
+func (s *shardedCounter) Decrement() int64 {
+	return s.add(-1)
+}
+
+func (s *shardedCounter) DecrementBy(num int) int64 {
+	return s.add(-int64(num))
+}
+
+func (s *shardedCounter) IncrementByDuration(d time.Duration) int64 {
+	return s.add(int64(d))
+}
+
+// Set isn't atomic with respect to concurrent updates.
+func (s *shardedCounter) Set(num int) {
+	s.store(int64(num))
+}
+
+func (s *shardedCounter) store(v int64) {
+	atomic.StoreInt64(&s.shards[0].value, v)
+	for i := 1; i < len(s.shards); i++ {
+		atomic.StoreInt64(&s.shards[i].value, 0)
+	}
+}
+
+// update applies fn to the total and adds the difference to a shard.
+// Updates with a function are serialized, so each of them sees the previous
+// ones, increments running concurrently are kept but fn may not see them.
+func (s *shardedCounter) update(fn func(old int64) int64) int64 {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+	old := s.Value()
+	n := fn(old)
+	s.add(n - old)
+	return n
+}
+
+func (s *shardedCounter) reset() {
+	s.store(0)
+	atomic.AddInt64(&s.resetEpoch, 1)
+}
+
+func (s *shardedCounter) epoch() int64 {
+	return atomic.LoadInt64(&s.resetEpoch)
+}
+
+func (s *shardedCounter) Kind() MetricKind {
+	return KindCounter
+}
+
+func (s *shardedCounter) Name() string {
+	return s.name
+}
+
+func (s *shardedCounter) Value() int64 {
+	var sum int64
+	for i := range s.shards {
+		sum += atomic.LoadInt64(&s.shards[i].value)
+	}
+	return sum
+}
+
+// GetShardedCounter returns a sharded counter of given name, if doesn't
+// exist than create. A sharded counter keeps a value per shard, which makes
+// concurrent updates much cheaper and reading the value more expensive.
+// Increment and the like return a value of the updated shard, not the total,
+// see Counter, use Value to get the total. If a regular counter of the name
+// already exists it's returned instead.
+func (c *CounterBox) GetShardedCounter(name string) Counter {
+	name = c.checkName(name)
+	value, ok := c.counters.Load(name)
+	if !ok {
+		c.claimName(name, KindCounter)
+		value, _ = c.counters.LoadOrStore(name, newShardedCounter(name))
+	}
+	v, _ := value.(Counter)
+	return v
+}
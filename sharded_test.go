@@ -0,0 +1,49 @@
+package counters
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestShardedCounter(t *testing.T) {
+	box := NewCounterBox()
+	var wg sync.WaitGroup
+	for x := 0; x < 10; x++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cnt := box.GetShardedCounter("test")
+			for y := 0; y < 1000; y++ {
+				cnt.Increment()
+				cnt.IncrementBy(3)
+				cnt.Decrement()
+			}
+		}()
+	}
+	wg.Wait()
+	if v := box.GetCounter("test").Value(); v != 30000 {
+		t.Errorf("got %d, expected 30000", v)
+	}
+	box.GetShardedCounter("test").Set(5)
+	if v := box.GetShardedCounter("test").Value(); v != 5 {
+		t.Errorf("got %d, expected 5", v)
+	}
+}
+
+func BenchmarkCounterParallel(b *testing.B) {
+	cnt := NewCounterBox().GetCounter("test")
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cnt.Increment()
+		}
+	})
+}
+
+func BenchmarkShardedCounterParallel(b *testing.B) {
+	cnt := NewCounterBox().GetShardedCounter("test")
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cnt.Increment()
+		}
+	})
+}
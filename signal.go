@@ -0,0 +1,28 @@
+package counters
+
+import (
+	"os"
+	"os/signal"
+	"time"
+)
+
+// InitCountersOnSignal logs values of all counters when the process gets
+// SIGINT or SIGTERM. After SIGTERM or a second SIGINT within a second the
+// process exits. On platforms without these signals, e.g. Windows, only
+// os.Interrupt is handled, the process exits on a second one within
+// a second.
+func InitCountersOnSignal(logger TrivialLogger, box Counters) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, logSignals...)
+	go func() {
+		lastInt := time.Now()
+		for sig := range sigs {
+			logger.Print(box.String())
+			l := time.Now()
+			if isTerminate(sig) || l.Sub(lastInt).Seconds() < 1. {
+				os.Exit(0)
+			}
+			lastInt = l
+		}
+	}()
+}
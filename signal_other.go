@@ -0,0 +1,14 @@
+//go:build !unix
+
+package counters
+
+import "os"
+
+// logSignals are signals handled by InitCountersOnSignal, only os.Interrupt
+// is guaranteed to be delivered on all platforms.
+var logSignals = []os.Signal{os.Interrupt}
+
+// isTerminate reports whether sig asks the process to terminate.
+func isTerminate(sig os.Signal) bool {
+	return false
+}
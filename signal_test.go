@@ -0,0 +1,21 @@
+package counters
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLogSignals(t *testing.T) {
+	found := false
+	for _, sig := range logSignals {
+		if sig == os.Interrupt {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("want os.Interrupt handled, got %v", logSignals)
+	}
+	if isTerminate(os.Interrupt) {
+		t.Error("os.Interrupt should not terminate at once")
+	}
+}
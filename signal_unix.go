@@ -0,0 +1,16 @@
+//go:build unix
+
+package counters
+
+import (
+	"os"
+	"syscall"
+)
+
+// logSignals are signals handled by InitCountersOnSignal.
+var logSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+
+// isTerminate reports whether sig asks the process to terminate.
+func isTerminate(sig os.Signal) bool {
+	return sig == syscall.SIGTERM
+}
@@ -0,0 +1,108 @@
+package counters
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// plainCounter is a counter without atomic operations, it must not be used
+// concurrently. Built with the race detector it panics on concurrent
+// updates.
+type plainCounter struct {
+	name  string
+	value int64
+	// resetEpoch is the number of resets, see ResetEpoch. Resets are rare
+	// and done by the box, so it's updated atomically. It's kept before busy
+	// to be 8-byte aligned also on 32-bit platforms.
+	resetEpoch int64
+	// busy is set during updates, it's used only with the race detector.
+	busy int32
+}
+
+func (p *plainCounter) enter() {
+	if raceEnabled && !atomic.CompareAndSwapInt32(&p.busy, 0, 1) {
+		panic("counters: concurrent use of counter " + p.name + " of a single threaded box")
+	}
+}
+
+func (p *plainCounter) exit() {
+	if raceEnabled {
+		atomic.StoreInt32(&p.busy, 0)
+	}
+}
+
+func (p *plainCounter) add(v int64) int64 {
+	p.enter()
+	p.value += v
+	n := p.value
+	p.exit()
+	return n
+}
+
+func (p *plainCounter) Increment() int64 {
+	return p.add(1)
+}
+
+func (p *plainCounter) IncrementBy(num int) int64 {
+	return p.add(int64(num))
+}
+
+func (p *plainCounter) Decrement() int64 {
+	return p.add(-1)
+}
+
+func (p *plainCounter) DecrementBy(num int) int64 {
+	return p.add(-int64(num))
+}
+
+func (p *plainCounter) IncrementByDuration(d time.Duration) int64 {
+	return p.add(int64(d))
+}
+
+func (p *plainCounter) Set(num int) {
+	p.store(int64(num))
+}
+
+func (p *plainCounter) update(fn func(old int64) int64) int64 {
+	p.enter()
+	p.value = fn(p.value)
+	n := p.value
+	p.exit()
+	return n
+}
+
+func (p *plainCounter) Kind() MetricKind {
+	return KindCounter
+}
+
+func (p *plainCounter) Name() string {
+	return p.name
+}
+
+func (p *plainCounter) Value() int64 {
+	return p.value
+}
+
+func (p *plainCounter) store(v int64) {
+	p.enter()
+	p.value = v
+	p.exit()
+}
+
+func (p *plainCounter) reset() {
+	p.store(0)
+	atomic.AddInt64(&p.resetEpoch, 1)
+}
+
+func (p *plainCounter) epoch() int64 {
+	return atomic.LoadInt64(&p.resetEpoch)
+}
+
+func (p *plainCounter) swapReset() int64 {
+	atomic.AddInt64(&p.resetEpoch, 1)
+	p.enter()
+	v := p.value
+	p.value = 0
+	p.exit()
+	return v
+}
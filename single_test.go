@@ -0,0 +1,69 @@
+package counters
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSingleThreaded(t *testing.T) {
+	box := NewCounterBox(WithUnsafeSingleThreaded())
+	c := box.GetCounter("test")
+	for i := 0; i < 1000; i++ {
+		c.Increment()
+	}
+	c.IncrementBy(10)
+	c.DecrementBy(3)
+	if v := box.GetCounter("test").Value(); v != 1007 {
+		t.Errorf("want: 1007, got %d", v)
+	}
+	if v := box.Update("test", func(old int64) int64 { return old * 2 }); v != 2014 {
+		t.Errorf("Update, want: 2014, got %d", v)
+	}
+	want := CounterSnapshot{
+		Counters: map[string]int64{"test": 2014},
+		Min:      map[string]int64{},
+		Max:      map[string]int64{},
+	}
+	if s := box.SnapshotAndReset(); !reflect.DeepEqual(s, want) {
+		t.Errorf("want: %v, got %v", want, s)
+	}
+	if v := c.Value(); v != 0 {
+		t.Errorf("after reset, want: 0, got %d", v)
+	}
+}
+
+func TestSingleThreadedConcurrentUse(t *testing.T) {
+	if !raceEnabled {
+		t.Skip("the guard works only with the race detector")
+	}
+	c := NewCounterBox(WithUnsafeSingleThreaded()).GetCounter("test").(*plainCounter)
+	// Simulate an update in progress in another goroutine.
+	c.enter()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic on concurrent use")
+			}
+		}()
+		c.Increment()
+	}()
+	wg.Wait()
+}
+
+func BenchmarkCounterIncrement(b *testing.B) {
+	c := NewCounterBox().GetCounter("test")
+	for i := 0; i < b.N; i++ {
+		c.Increment()
+	}
+}
+
+func BenchmarkSingleThreadedIncrement(b *testing.B) {
+	c := NewCounterBox(WithUnsafeSingleThreaded()).GetCounter("test")
+	for i := 0; i < b.N; i++ {
+		c.Increment()
+	}
+}
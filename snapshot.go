@@ -0,0 +1,244 @@
+package counters
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CounterSnapshot keeps values of all counters of a box at some moment.
+type CounterSnapshot struct {
+	Counters map[string]int64
+	Min      map[string]int64
+	Max      map[string]int64
+	// Tags keeps tags set by SetTags by name, it's nil if there are none.
+	Tags map[string]map[string]string
+}
+
+// Snapshot returns values of all counters. The values are read under the box
+// read lock, so they are consistent with operations modifying several
+// counters at once.
+func (c *CounterBox) Snapshot() CounterSnapshot {
+	c.mu.RLock()
+	s := CounterSnapshot{
+		Counters: pairsToMap(collectLocked(c.counters)),
+		Min:      pairsToMap(collectLocked(c.min)),
+		Max:      pairsToMap(collectLocked(c.max)),
+		Tags:     c.allTags(),
+	}
+	c.mu.RUnlock()
+	addPairs(s.Counters, c.computedPairs())
+	return s
+}
+
+// addPairs sets values of pairs in m.
+func addPairs(m map[string]int64, pairs []CounterPair) {
+	for _, p := range pairs {
+		m[p.Name] = p.Value
+	}
+}
+
+// changed returns values of cur which are missing in or differ from prev.
+func changed(cur, prev map[string]int64) map[string]int64 {
+	m := make(map[string]int64)
+	for name, v := range cur {
+		if p, ok := prev[name]; !ok || p != v {
+			m[name] = v
+		}
+	}
+	return m
+}
+
+// sortedNames returns keys of m sorted.
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ChangedSince returns a snapshot of counters which values differ from the
+// ones in prev, including counters which don't exist in prev.
+func (c *CounterBox) ChangedSince(prev CounterSnapshot) CounterSnapshot {
+	cur := c.Snapshot()
+	return CounterSnapshot{
+		Counters: changed(cur.Counters, prev.Counters),
+		Min:      changed(cur.Min, prev.Min),
+		Max:      changed(cur.Max, prev.Max),
+		Tags:     cur.Tags,
+	}
+}
+
+// swapper is implemented by counters which can be reset to their initial
+// value returning the previous one in a single atomic operation.
+type swapper interface {
+	swapReset() int64
+}
+
+func (c *counterImpl) swapReset() int64 {
+	c.touch()
+	atomic.AddInt64(&c.resetEpoch, 1)
+	return atomic.SwapInt64(&c.value, 0)
+}
+
+func (m *maxImpl) swapReset() int64 {
+	return atomic.SwapInt64(&m.value, 0)
+}
+
+func (m *minImpl) swapReset() int64 {
+	return atomic.SwapInt64(&m.value, math.MaxInt64)
+}
+
+func (s *shardedCounter) swapReset() int64 {
+	atomic.AddInt64(&s.resetEpoch, 1)
+	var sum int64
+	for i := range s.shards {
+		sum += atomic.SwapInt64(&s.shards[i].value, 0)
+	}
+	return sum
+}
+
+// readAndReset returns values kept in m and resets counters to their initial
+// values, names of the reset counters are appended to reset. Counters which
+// can't be reset are only read, computed ones are skipped, see
+// computedPairs.
+func readAndReset(m *sync.Map, reset *[]string) map[string]int64 {
+	values := make(map[string]int64)
+	m.Range(func(key interface{}, value interface{}) bool {
+		name := key.(string)
+		switch v := value.(type) {
+		case *computedCounter:
+		case swapper:
+			values[name] = v.swapReset()
+			*reset = append(*reset, name)
+		case namedValue:
+			values[name] = v.Value()
+		}
+		return true
+	})
+	return values
+}
+
+// SnapshotAndReset returns values of all counters and resets them to their
+// initial values in one operation under the box lock. Every counter is read
+// and reset atomically, so concurrent updates are either included in the
+// snapshot or kept in the box, they are never lost or reported twice.
+func (c *CounterBox) SnapshotAndReset() CounterSnapshot {
+	var reset []string
+	c.mu.Lock()
+	s := CounterSnapshot{
+		Counters: readAndReset(c.counters, &reset),
+		Min:      readAndReset(c.min, &reset),
+		Max:      readAndReset(c.max, &reset),
+		Tags:     c.allTags(),
+	}
+	c.mu.Unlock()
+	addPairs(s.Counters, c.computedPairs())
+	c.notifyReset(reset)
+	return s
+}
+
+// TimedSnapshot is a snapshot with the time it was taken.
+type TimedSnapshot struct {
+	Time time.Time
+	CounterSnapshot
+}
+
+// snapshotRing keeps a limited number of the most recent snapshots.
+type snapshotRing struct {
+	mu    sync.Mutex
+	buf   []TimedSnapshot
+	start int
+	n     int
+}
+
+func newSnapshotRing(size int) *snapshotRing {
+	return &snapshotRing{buf: make([]TimedSnapshot, size)}
+}
+
+func (r *snapshotRing) add(s TimedSnapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.n < len(r.buf) {
+		r.buf[(r.start+r.n)%len(r.buf)] = s
+		r.n++
+		return
+	}
+	r.buf[r.start] = s
+	r.start = (r.start + 1) % len(r.buf)
+}
+
+// list returns the snapshots starting from the oldest.
+func (r *snapshotRing) list() []TimedSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l := make([]TimedSnapshot, r.n)
+	for i := range l {
+		l[i] = r.buf[(r.start+i)%len(r.buf)]
+	}
+	return l
+}
+
+// EnableHistory starts taking a snapshot of the box every interval and keeps
+// the last keep of them, see History. It returns a function which stops
+// taking snapshots. Calling EnableHistory again stops taking the previous
+// snapshots and replaces the history.
+func (c *CounterBox) EnableHistory(every time.Duration, keep int) (stop func()) {
+	if keep < 1 {
+		keep = 1
+	}
+	r := newSnapshotRing(keep)
+	c.historyMu.Lock()
+	defer c.historyMu.Unlock()
+	if c.historyStop != nil {
+		c.historyStop()
+	}
+	c.history = r
+	c.historyStop = c.every(every, func() {
+		r.add(TimedSnapshot{c.clock.Now(), c.Snapshot()})
+	})
+	return c.historyStop
+}
+
+// History returns snapshots recorded since EnableHistory was called,
+// starting from the oldest.
+func (c *CounterBox) History() []TimedSnapshot {
+	c.historyMu.Lock()
+	r := c.history
+	c.historyMu.Unlock()
+	if r == nil {
+		return nil
+	}
+	return r.list()
+}
+
+// every calls fn at every tick of the box clock until the returned function
+// is called.
+func (c *CounterBox) every(d time.Duration, fn func()) (stop func()) {
+	t := c.clock.NewTicker(d)
+	done := make(chan struct{})
+	go func() {
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C():
+				// A tick and a stop may be ready at once, fn must not
+				// run after stop returned.
+				select {
+				case <-done:
+					return
+				default:
+				}
+				fn()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
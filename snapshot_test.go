@@ -0,0 +1,169 @@
+package counters
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("a").IncrementBy(2)
+	box.GetMin("b").Set(3)
+	box.GetMax("c").Set(4)
+	want := CounterSnapshot{
+		Counters: map[string]int64{"a": 2},
+		Min:      map[string]int64{"b": 3},
+		Max:      map[string]int64{"c": 4},
+	}
+	if got := box.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+}
+
+func TestChangedSince(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("same").IncrementBy(1)
+	box.GetCounter("changed").IncrementBy(2)
+	box.GetMax("max").Set(3)
+	box.GetMin("min").Set(4)
+	prev := box.Snapshot()
+
+	box.GetCounter("changed").Increment()
+	box.GetCounter("new").Increment()
+	box.GetMax("max").Set(5)
+	box.GetMin("min").Set(6)
+
+	want := CounterSnapshot{
+		Counters: map[string]int64{"changed": 3, "new": 1},
+		Min:      map[string]int64{},
+		Max:      map[string]int64{"max": 5},
+	}
+	if got := box.ChangedSince(prev); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+	if got := box.ChangedSince(CounterSnapshot{}); !reflect.DeepEqual(got, box.Snapshot()) {
+		t.Errorf("everything changed since an empty snapshot, got %v", got)
+	}
+}
+
+func TestSnapshotAndReset(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("a").IncrementBy(2)
+	box.GetShardedCounter("s").IncrementBy(3)
+	box.GetMin("b").Set(3)
+	box.GetMax("c").Set(4)
+	want := box.Snapshot()
+	if got := box.SnapshotAndReset(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+	want = CounterSnapshot{
+		Counters: map[string]int64{"a": 0, "s": 0},
+		Min:      map[string]int64{"b": math.MaxInt64},
+		Max:      map[string]int64{"c": 0},
+	}
+	if got := box.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after reset, want: %v, got %v", want, got)
+	}
+}
+
+func TestSnapshotAndResetConcurrent(t *testing.T) {
+	box := NewCounterBox()
+	var wg sync.WaitGroup
+	for x := 0; x < 10; x++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := 0; y < 1000; y++ {
+				box.GetCounter("test").Increment()
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	var sum int64
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		sum += box.SnapshotAndReset().Counters["test"]
+	}
+	sum += box.GetCounter("test").Value()
+	if sum != 10000 {
+		t.Errorf("want 10000 increments in total, got %d", sum)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	cnt := box.GetCounter("test")
+	stop := box.EnableHistory(time.Minute, 3)
+	defer stop()
+
+	start := clock.Now()
+	for i := 1; i <= 5; i++ {
+		cnt.Increment()
+		clock.Advance(time.Minute)
+		waitFor(t, func() bool {
+			h := box.History()
+			return len(h) > 0 && h[len(h)-1].Time.Equal(clock.Now())
+		})
+	}
+	if n := len(box.History()); n != 3 {
+		t.Fatalf("want 3 snapshots, got %d", n)
+	}
+	for i, s := range box.History() {
+		if want := int64(i + 3); s.Counters["test"] != want {
+			t.Errorf("snapshot %d, want: %d, got %d", i, want, s.Counters["test"])
+		}
+		if want := start.Add(time.Duration(i+3) * time.Minute); !s.Time.Equal(want) {
+			t.Errorf("snapshot %d, want time %v, got %v", i, want, s.Time)
+		}
+	}
+}
+
+func TestHistoryStop(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	stop := box.EnableHistory(time.Minute, 3)
+	clock.Advance(time.Minute)
+	waitFor(t, func() bool { return len(box.History()) == 1 })
+	stop()
+	clock.Advance(time.Minute)
+	if n := len(box.History()); n != 1 {
+		t.Errorf("want 1 snapshot after stop, got %d", n)
+	}
+}
+
+func TestHistoryRestart(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	// Every snapshot evaluates the computed counter.
+	var snapshots int64
+	box.RegisterComputed("snapshots", func(*CounterBox) int64 {
+		return atomic.AddInt64(&snapshots, 1)
+	})
+	first := box.EnableHistory(time.Minute, 3)
+	stop := box.EnableHistory(time.Hour, 3)
+	defer stop()
+	for i := 0; i < 60; i++ {
+		clock.Advance(time.Minute)
+	}
+	waitFor(t, func() bool { return len(box.History()) == 1 })
+	if n := atomic.LoadInt64(&snapshots); n != 1 {
+		t.Errorf("want the replaced history stopped, got %d snapshots", n)
+	}
+	// Stopping the replaced history doesn't stop the new one.
+	first()
+	clock.Advance(time.Hour)
+	waitFor(t, func() bool { return len(box.History()) == 2 })
+}
@@ -0,0 +1,81 @@
+package counters
+
+import "sort"
+
+// SetTags attaches tags, e.g. environment or region, to the counter, the min
+// and the max value of given name. Tags are printed by the JSON, Prometheus
+// and InfluxDB outputs and returned by Snapshot. The map is copied, so later
+// changes of tags don't affect the box. Empty tags remove the tags.
+func (c *CounterBox) SetTags(name string, tags map[string]string) {
+	name = c.checkName(name)
+	if len(tags) == 0 {
+		c.tags.Delete(name)
+		return
+	}
+	c.tags.Store(name, copyTags(tags))
+}
+
+// Tags returns a copy of tags set by SetTags for name, or nil.
+func (c *CounterBox) Tags(name string) map[string]string {
+	value, ok := c.tags.Load(c.checkName(name))
+	if !ok {
+		return nil
+	}
+	return copyTags(value.(map[string]string))
+}
+
+func copyTags(tags map[string]string) map[string]string {
+	cp := make(map[string]string, len(tags))
+	for k, v := range tags {
+		cp[k] = v
+	}
+	return cp
+}
+
+// sortedTags returns tags of name sorted by name.
+func (c *CounterBox) sortedTags(name string) []Label {
+	value, ok := c.tags.Load(name)
+	if !ok {
+		return nil
+	}
+	tags := value.(map[string]string)
+	labels := make([]Label, 0, len(tags))
+	for k, v := range tags {
+		labels = append(labels, Label{k, v})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels
+}
+
+// exportTags returns tags of name merged with the const labels sorted by
+// name, a const label replaces a tag of the same name.
+func (c *CounterBox) exportTags(name string) []Label {
+	tags := c.sortedTags(name)
+	if len(c.constLabels) == 0 {
+		return tags
+	}
+	labels := make([]Label, 0, len(tags)+len(c.constLabels))
+	for _, t := range tags {
+		if _, ok := c.constLabels[t.Name]; !ok {
+			labels = append(labels, t)
+		}
+	}
+	for k, v := range c.constLabels {
+		labels = append(labels, Label{k, v})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels
+}
+
+// allTags returns copies of tags of all names, or nil if there are none.
+func (c *CounterBox) allTags() map[string]map[string]string {
+	var all map[string]map[string]string
+	c.tags.Range(func(key interface{}, value interface{}) bool {
+		if all == nil {
+			all = make(map[string]map[string]string)
+		}
+		all[key.(string)] = copyTags(value.(map[string]string))
+		return true
+	})
+	return all
+}
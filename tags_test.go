@@ -0,0 +1,134 @@
+package counters
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetTags(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("requests").IncrementBy(3)
+	tags := map[string]string{"env": "prod", "region": "eu"}
+	box.SetTags("requests", tags)
+	tags["env"] = "changed"
+
+	want := map[string]string{"env": "prod", "region": "eu"}
+	if got := box.Tags("requests"); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got %v", want, got)
+	}
+	box.Tags("requests")["env"] = "changed"
+	if got := box.Snapshot().Tags; !reflect.DeepEqual(got, map[string]map[string]string{"requests": want}) {
+		t.Errorf("snapshot, want: %v, got %v", want, got)
+	}
+
+	box.SetTags("requests", nil)
+	if got := box.Tags("requests"); got != nil {
+		t.Errorf("want no tags, got %v", got)
+	}
+	if got := box.Snapshot().Tags; got != nil {
+		t.Errorf("want no tags in snapshot, got %v", got)
+	}
+}
+
+func TestTagsJSON(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("requests").IncrementBy(3)
+	box.SetTags("requests", map[string]string{"env": "prod"})
+	data, err := json.Marshal(box)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"counters":{"requests":3},"min":{},"max":{},"tags":{"requests":{"env":"prod"}}}`
+	if string(data) != want {
+		t.Errorf("want: %s, got %s", want, data)
+	}
+	restored, err := FromJSON(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored.Snapshot(), box.Snapshot()) {
+		t.Errorf("want: %v, got %v", box.Snapshot(), restored.Snapshot())
+	}
+}
+
+func TestTagsPrometheus(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("requests").IncrementBy(3)
+	box.GetMax("requests").Set(5)
+	box.SetTags("requests", map[string]string{"region": `e"u`, "env": "prod"})
+	var b strings.Builder
+	if err := box.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\nrequests{env=\"prod\",region=\"e\\\"u\"} 3\n",
+		"\nrequests_max{env=\"prod\",region=\"e\\\"u\"} 5\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("want %q in:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestTagsInflux(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	box.GetCounter("requests").IncrementBy(3)
+	box.SetTags("requests", map[string]string{"region": "eu west", "env": "prod"})
+	var b strings.Builder
+	if err := box.WriteInflux(&b, "app"); err != nil {
+		t.Fatal(err)
+	}
+	want := "app,name=requests,env=prod,region=eu\\ west value=3i 1577836800000000000\n"
+	if b.String() != want {
+		t.Errorf("want: %q, got %q", want, b.String())
+	}
+}
+
+func TestConstLabels(t *testing.T) {
+	clock := newFakeClock()
+	labels := map[string]string{"service": "api", "env": "prod"}
+	box := NewCounterBox(WithClock(clock), WithConstLabels(labels))
+	labels["service"] = "changed"
+	box.GetCounter("requests").IncrementBy(3)
+	box.GetMin("latency").Set(2)
+	box.SetTags("requests", map[string]string{"env": "dev", "region": "eu"})
+
+	var prom strings.Builder
+	if err := box.WritePrometheus(&prom); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\nrequests{env=\"prod\",region=\"eu\",service=\"api\"} 3\n",
+		"\nlatency_min{env=\"prod\",service=\"api\"} 2\n",
+	} {
+		if !strings.Contains(prom.String(), want) {
+			t.Errorf("want %q in:\n%s", want, prom.String())
+		}
+	}
+
+	var influx strings.Builder
+	if err := box.WriteInflux(&influx, "app"); err != nil {
+		t.Fatal(err)
+	}
+	want := "app,name=requests,env=prod,region=eu,service=api value=3i 1577836800000000000\n" +
+		"app,name=latency,env=prod,service=api min=2i 1577836800000000000\n"
+	if influx.String() != want {
+		t.Errorf("want: %q, got %q", want, influx.String())
+	}
+
+	data, err := json.Marshal(box)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `{"counters":{"requests":3},"min":{"latency":2},"max":{},` +
+		`"tags":{"requests":{"env":"dev","region":"eu"}},"labels":{"env":"prod","service":"api"}}`
+	if string(data) != want {
+		t.Errorf("want: %s, got %s", want, data)
+	}
+	if _, err := FromJSON(strings.NewReader(string(data))); err != nil {
+		t.Errorf("want JSON with labels accepted, got %v", err)
+	}
+}
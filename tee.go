@@ -0,0 +1,81 @@
+package counters
+
+import "time"
+
+// teeCounter applies updates to several counters, it reads the first one.
+type teeCounter struct {
+	name     string
+	counters []Counter
+}
+
+func (t *teeCounter) Increment() int64 {
+	return t.IncrementBy(1)
+}
+
+func (t *teeCounter) IncrementBy(num int) int64 {
+	var v int64
+	for i, c := range t.counters {
+		if n := c.IncrementBy(num); i == 0 {
+			v = n
+		}
+	}
+	return v
+}
+
+func (t *teeCounter) Decrement() int64 {
+	return t.DecrementBy(1)
+}
+
+func (t *teeCounter) DecrementBy(num int) int64 {
+	var v int64
+	for i, c := range t.counters {
+		if n := c.DecrementBy(num); i == 0 {
+			v = n
+		}
+	}
+	return v
+}
+
+func (t *teeCounter) IncrementByDuration(d time.Duration) int64 {
+	var v int64
+	for i, c := range t.counters {
+		if n := c.IncrementByDuration(d); i == 0 {
+			v = n
+		}
+	}
+	return v
+}
+
+func (t *teeCounter) Set(num int) {
+	for _, c := range t.counters {
+		c.Set(num)
+	}
+}
+
+func (t *teeCounter) Name() string {
+	return t.name
+}
+
+// Value returns a value of the counter in the first box.
+func (t *teeCounter) Value() int64 {
+	if len(t.counters) == 0 {
+		return 0
+	}
+	return t.counters[0].Value()
+}
+
+func (t *teeCounter) Kind() MetricKind {
+	return KindCounter
+}
+
+// TeeCounter returns a counter which applies every update to counters of
+// given name in all boxes, e.g. a local box and a global aggregate.
+// Increment and the like return, and Value reads, the counter in the first
+// box. The boxes are updated one after another, not atomically.
+func TeeCounter(name string, boxes ...*CounterBox) Counter {
+	t := &teeCounter{name: name, counters: make([]Counter, len(boxes))}
+	for i, box := range boxes {
+		t.counters[i] = box.GetCounter(name)
+	}
+	return t
+}
@@ -0,0 +1,35 @@
+package counters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTeeCounter(t *testing.T) {
+	local, global := NewCounterBox(), NewCounterBox()
+	global.GetCounter("requests").IncrementBy(100)
+	tee := TeeCounter("requests", local, global)
+
+	if v := tee.Increment(); v != 1 {
+		t.Errorf("Increment, want: 1, got %d", v)
+	}
+	tee.IncrementBy(5)
+	tee.Decrement()
+	tee.IncrementByDuration(3 * time.Nanosecond)
+	if v := local.GetCounter("requests").Value(); v != 8 {
+		t.Errorf("local, want: 8, got %d", v)
+	}
+	if v := global.GetCounter("requests").Value(); v != 108 {
+		t.Errorf("global, want: 108, got %d", v)
+	}
+	if v := tee.Value(); v != 8 {
+		t.Errorf("tee, want: 8, got %d", v)
+	}
+	tee.Set(2)
+	if v := global.GetCounter("requests").Value(); v != 2 {
+		t.Errorf("global after Set, want: 2, got %d", v)
+	}
+	if v := TeeCounter("empty").Increment(); v != 0 {
+		t.Errorf("no boxes, want: 0, got %d", v)
+	}
+}
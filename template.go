@@ -0,0 +1,82 @@
+package counters
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"text/template"
+)
+
+// comma formats v with thousands separated by commas, e.g. 1,234,567.
+func comma(v int64) string {
+	s := strconv.FormatInt(v, 10)
+	sign := ""
+	if v < 0 {
+		sign, s = "-", s[1:]
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return sign + s
+}
+
+// humanize formats v as a number of bytes using binary units, e.g. 1.5 KiB.
+func humanize(v int64) string {
+	const unit = 1024
+	if v < unit && v > -unit {
+		return fmt.Sprintf("%d B", v)
+	}
+	f := float64(v)
+	exp := 0
+	for ; (f >= unit || f <= -unit) && exp < 6; exp++ {
+		f /= unit
+	}
+	return fmt.Sprintf("%.1f %ciB", f, "KMGTPE"[exp-1])
+}
+
+// si formats v with an SI suffix and one decimal digit, e.g. 12.0M.
+// Values between -1000 and 1000 are formatted as they are.
+func si(v int64) string {
+	const unit = 1000
+	if v < unit && v > -unit {
+		return strconv.FormatInt(v, 10)
+	}
+	f := float64(v)
+	exp := 0
+	// Values which would round to 1000.0 get the next suffix.
+	for ; (f >= unit-0.05 || f <= -unit+0.05) && exp < 6; exp++ {
+		f /= unit
+	}
+	return fmt.Sprintf("%.1f%c", f, "KMGTPE"[exp-1])
+}
+
+// siMin works like si, but it doesn't format math.MaxInt64, the value of
+// a min value which was never set.
+func siMin(v int64) string {
+	if v == math.MaxInt64 {
+		return strconv.FormatInt(v, 10)
+	}
+	return si(v)
+}
+
+// FuncMap returns functions which can be used in templates passed to
+// WriteToTemplate:
+//
+//	comma    formats a value with thousands separators, e.g. 1,234,567
+//	humanize formats a value as bytes, e.g. 1.5 KiB
+//	si       formats a value with an SI suffix, e.g. 12.0M
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"comma":    comma,
+		"humanize": humanize,
+		"si":       si,
+	}
+}
+
+// WriteToTemplate renders all counters with t. The template gets
+// a *RenderData with Counters, Min and Max fields, each is a slice of
+// CounterPair sorted by name, and the other values of the box.
+func (c *CounterBox) WriteToTemplate(w io.Writer, t *template.Template) error {
+	return t.Execute(w, c.newRenderData(c.Pairs(), c.MinPairs(), c.MaxPairs()))
+}
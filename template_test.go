@@ -0,0 +1,128 @@
+package counters
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestComma(t *testing.T) {
+	for v, want := range map[int64]string{
+		0:        "0",
+		999:      "999",
+		1000:     "1,000",
+		1234567:  "1,234,567",
+		-1234567: "-1,234,567",
+	} {
+		if got := comma(v); got != want {
+			t.Errorf("comma(%d), want: %q, got %q", v, want, got)
+		}
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	for v, want := range map[int64]string{
+		12:      "12 B",
+		1536:    "1.5 KiB",
+		5 << 20: "5.0 MiB",
+		3 << 40: "3.0 TiB",
+	} {
+		if got := humanize(v); got != want {
+			t.Errorf("humanize(%d), want: %q, got %q", v, want, got)
+		}
+	}
+}
+
+func TestSI(t *testing.T) {
+	for v, want := range map[int64]string{
+		0:                  "0",
+		999:                "999",
+		-999:               "-999",
+		1000:               "1.0K",
+		1234:               "1.2K",
+		-45600:             "-45.6K",
+		999949:             "999.9K",
+		999950:             "1.0M",
+		12034567:           "12.0M",
+		3_500_000_000:      "3.5G",
+		math.MaxInt64:      "9.2E",
+		7_000_000_000_000:  "7.0T",
+		15_000_000_000_000: "15.0T",
+	} {
+		if got := si(v); got != want {
+			t.Errorf("si(%d), want: %q, got %q", v, want, got)
+		}
+	}
+}
+
+func TestWriteToHumanReadable(t *testing.T) {
+	box := NewCounterBox(WithHumanReadable())
+	box.GetCounter("bytes").IncrementBy(12034567)
+	box.GetCounter("small").IncrementBy(42)
+	box.GetMax("latency").Set(1500)
+	box.GetMin("latency").Set(2500)
+	box.DeclareMin("unset")
+	want := `== Counters ==
+  bytes: 12.0M
+  small: 42
+== Min values ==
+  latency: 2.5K
+  unset: 9223372036854775807
+== Max values ==
+  latency: 1.5K`
+	if s := box.String(); s != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, s)
+	}
+	data, _ := box.MarshalJSON()
+	if !strings.Contains(string(data), "12034567") {
+		t.Errorf("want exact values in JSON, got %s", data)
+	}
+}
+
+func TestWriteToTemplate(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("bytes").IncrementBy(1234567)
+	tmpl := template.Must(template.New("t").Funcs(FuncMap()).Parse(
+		`{{range .Counters}}{{.Name}}={{comma .Value}} ({{humanize .Value}}){{end}}`))
+
+	buf := &strings.Builder{}
+	if err := box.WriteToTemplate(buf, tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if want := "bytes=1,234,567 (1.2 MiB)"; buf.String() != want {
+		t.Errorf("want: %q, got %q", want, buf.String())
+	}
+}
+
+func TestRenderData(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock), WithLabel("box"), WithRenderTime())
+	for _, name := range []string{"c", "a", "b"} {
+		box.GetCounter(name).IncrementBy(len(name))
+		box.GetMax(name).Set(2)
+	}
+	box.GetMin("z").Set(1)
+	box.GetMin("y").Set(2)
+	data := box.RenderData()
+	want := RenderData{
+		Label:      "box",
+		Counters:   []CounterPair{{"a", 1}, {"b", 1}, {"c", 1}},
+		Min:        []CounterPair{{"y", 2}, {"z", 1}},
+		Max:        []CounterPair{{"a", 2}, {"b", 2}, {"c", 2}},
+		RenderedAt: "2020-01-01T00:00:00Z",
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("want: %+v, got %+v", want, data)
+	}
+
+	tm := template.Must(template.New("").Parse(`{{range .Counters}}{{.Name}}={{.Value}} {{end}}`))
+	var b strings.Builder
+	if err := tm.Execute(&b, data); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != "a=1 b=1 c=1 " {
+		t.Errorf("want: %q, got %q", "a=1 b=1 c=1 ", got)
+	}
+}
@@ -0,0 +1,73 @@
+package counters
+
+import (
+	"sync"
+	"time"
+)
+
+// throttledCounter records increments only while its token bucket has
+// tokens, other increments are counted in dropped.
+type throttledCounter struct {
+	Counter
+	dropped Counter
+	clock   Clock
+	perSec  float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// take reports whether a token was available and takes it. The bucket
+// refills at perSec tokens a second and holds at most perSec tokens.
+func (t *throttledCounter) take() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.clock.Now()
+	if elapsed := now.Sub(t.last); elapsed > 0 {
+		t.tokens = min(t.perSec, t.tokens+elapsed.Seconds()*t.perSec)
+	}
+	t.last = now
+	if t.tokens < 1 {
+		return false
+	}
+	t.tokens--
+	return true
+}
+
+func (t *throttledCounter) Increment() int64 {
+	return t.IncrementBy(1)
+}
+
+func (t *throttledCounter) IncrementBy(num int) int64 {
+	if !t.take() {
+		t.dropped.Increment()
+		return t.Counter.Value()
+	}
+	return t.Counter.IncrementBy(num)
+}
+
+// GetThrottledCounter returns a counter of given name which records at most
+// perSec increments a second, with bursts of up to perSec increments.
+// Increments above the limit are dropped and counted in counter
+// name.dropped. Every call of Increment or IncrementBy is one increment,
+// other updates aren't throttled. It shares the value with the counter
+// returned by GetCounter and the limit with other throttled counters of the
+// name, the limit of an existing one is not changed. The time is measured
+// with the box clock.
+func (c *CounterBox) GetThrottledCounter(name string, perSec int) Counter {
+	name = c.checkName(name)
+	value, ok := c.throttled.Load(name)
+	if !ok {
+		value, _ = c.throttled.LoadOrStore(name, &throttledCounter{
+			Counter: c.GetCounter(name),
+			dropped: c.GetCounter(name + ".dropped"),
+			clock:   c.clock,
+			perSec:  float64(perSec),
+			tokens:  float64(perSec),
+			last:    c.clock.Now(),
+		})
+	}
+	v, _ := value.(Counter)
+	return v
+}
@@ -0,0 +1,44 @@
+package counters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottledCounter(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	cnt := box.GetThrottledCounter("events", 10)
+
+	attempts := 0
+	for i := 0; i < 25; i++ {
+		cnt.Increment()
+		attempts++
+	}
+	if v := cnt.Value(); v != 10 {
+		t.Errorf("burst, want: 10, got %d", v)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	for i := 0; i < 8; i++ {
+		box.GetThrottledCounter("events", 100).Increment()
+		attempts++
+	}
+	if v := cnt.Value(); v != 15 {
+		t.Errorf("after half a second, want: 15, got %d", v)
+	}
+
+	clock.Advance(time.Hour)
+	for i := 0; i < 12; i++ {
+		cnt.IncrementBy(2)
+		attempts++
+	}
+	// 10 increments of the burst, 5 after half a second and 10 of 2.
+	if v := cnt.Value(); v != 35 {
+		t.Errorf("after refill, want: 35, got %d", v)
+	}
+	accepted := 10 + 5 + 10
+	if dropped := box.GetCounter("events.dropped").Value(); accepted+int(dropped) != attempts {
+		t.Errorf("want accepted+dropped: %d, got %d+%d", attempts, accepted, dropped)
+	}
+}
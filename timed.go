@@ -0,0 +1,147 @@
+package counters
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TimedMaxMin is a minima or maxima counter which remembers when its value
+// was set.
+type TimedMaxMin interface {
+	MaxMinValue
+	// ValueAt returns the current value and the time it was set at, the
+	// time is zero if the value is the initial one.
+	ValueAt() (int64, time.Time)
+}
+
+// timedValue is a value with the time it was set at.
+type timedValue struct {
+	value int64
+	at    time.Time
+}
+
+// timedExtreme keeps an extreme value together with its time, both are
+// replaced at once by swapping a pointer.
+type timedExtreme struct {
+	name    string
+	kind    MetricKind
+	clock   Clock
+	initial int64
+	// better reports whether v should replace the current value.
+	better func(v, cur int64) bool
+	cur    atomic.Pointer[timedValue]
+}
+
+func newTimedExtreme(name string, kind MetricKind, clock Clock) *timedExtreme {
+	t := &timedExtreme{name: name, kind: kind, clock: clock}
+	if kind == KindMin {
+		t.initial = math.MaxInt64
+		t.better = func(v, cur int64) bool { return v < cur }
+	} else {
+		t.better = func(v, cur int64) bool { return v > cur }
+	}
+	t.cur.Store(&timedValue{value: t.initial})
+	return t
+}
+
+func (t *timedExtreme) set(v int64) {
+	var n *timedValue
+	for {
+		o := t.cur.Load()
+		if !t.better(v, o.value) {
+			return
+		}
+		if n == nil {
+			n = &timedValue{v, t.clock.Now()}
+		}
+		if t.cur.CompareAndSwap(o, n) {
+			return
+		}
+	}
+}
+
+func (t *timedExtreme) Set(v int) {
+	t.set(int64(v))
+}
+
+func (t *timedExtreme) SetDuration(d time.Duration) {
+	t.set(int64(d))
+}
+
+func (t *timedExtreme) SetMany(values []int) {
+	if len(values) == 0 {
+		return
+	}
+	best := int64(values[0])
+	for _, v := range values[1:] {
+		if t.better(int64(v), best) {
+			best = int64(v)
+		}
+	}
+	t.set(best)
+}
+
+func (t *timedExtreme) ResetTo(v int) {
+	t.store(int64(v))
+}
+
+func (t *timedExtreme) store(v int64) {
+	t.cur.Store(&timedValue{v, t.clock.Now()})
+}
+
+func (t *timedExtreme) reset() {
+	t.cur.Store(&timedValue{value: t.initial})
+}
+
+func (t *timedExtreme) swapReset() int64 {
+	return t.cur.Swap(&timedValue{value: t.initial}).value
+}
+
+func (t *timedExtreme) Name() string {
+	return t.name
+}
+
+func (t *timedExtreme) Value() int64 {
+	return t.cur.Load().value
+}
+
+func (t *timedExtreme) Kind() MetricKind {
+	return t.kind
+}
+
+func (t *timedExtreme) ValueAt() (int64, time.Time) {
+	v := t.cur.Load()
+	return v.value, v.at
+}
+
+// getTimed returns a timed extreme of given name and kind kept in m, if
+// doesn't exist than create.
+func (c *CounterBox) getTimed(m *sync.Map, name string, kind MetricKind) TimedMaxMin {
+	name = c.checkName(name)
+	value, ok := m.Load(name)
+	if !ok {
+		c.claimName(name, kind)
+		value, _ = m.LoadOrStore(name, newTimedExtreme(name, kind, c.clock))
+	}
+	v, ok := value.(TimedMaxMin)
+	if !ok {
+		panic(fmt.Sprintf("counters: %s %q was created without time", kind, name))
+	}
+	return v
+}
+
+// GetMaxWithTime returns a maxima counter of given name which remembers the
+// time of the box clock when its value was set, if doesn't exist than
+// create. GetMax returns the same value. Unlike other max values it can't be
+// disabled. It panics if the max value was already created by GetMax.
+func (c *CounterBox) GetMaxWithTime(name string) TimedMaxMin {
+	return c.getTimed(c.max, name, KindMax)
+}
+
+// GetMinWithTime works like GetMaxWithTime for a minima counter.
+func (c *CounterBox) GetMinWithTime(name string) TimedMaxMin {
+	return c.getTimed(c.min, name, KindMin)
+}
@@ -0,0 +1,86 @@
+package counters
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetMaxWithTime(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	m := box.GetMaxWithTime("latency")
+	if v, at := m.ValueAt(); v != 0 || !at.IsZero() {
+		t.Errorf("initial, want: 0 and zero time, got %d %v", v, at)
+	}
+
+	for _, v := range []int{5, 10, 20} {
+		clock.Advance(time.Minute)
+		m.Set(v)
+		if got, at := m.ValueAt(); got != int64(v) || !at.Equal(clock.Now()) {
+			t.Errorf("want: %d at %v, got %d at %v", v, clock.Now(), got, at)
+		}
+	}
+	peak := clock.Now()
+	clock.Advance(time.Minute)
+	box.GetMax("latency").Set(15)
+	if v, at := m.ValueAt(); v != 20 || !at.Equal(peak) {
+		t.Errorf("lower value, want: 20 at %v, got %d at %v", peak, v, at)
+	}
+	if !strings.Contains(box.String(), "latency: 20") {
+		t.Errorf("want the max printed, got:\n%s", box.String())
+	}
+
+	box.SnapshotAndReset()
+	if v, at := m.ValueAt(); v != 0 || !at.IsZero() {
+		t.Errorf("after reset, want: 0 and zero time, got %d %v", v, at)
+	}
+}
+
+func TestGetMinWithTime(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	m := box.GetMinWithTime("latency")
+	if v := m.Value(); v != math.MaxInt64 {
+		t.Errorf("initial, want: %d, got %d", int64(math.MaxInt64), v)
+	}
+	m.Set(10)
+	at := clock.Now()
+	clock.Advance(time.Minute)
+	m.SetMany([]int{30, 20})
+	if v, got := m.ValueAt(); v != 10 || !got.Equal(at) {
+		t.Errorf("want: 10 at %v, got %d at %v", at, v, got)
+	}
+}
+
+func TestGetMaxWithTimeConcurrent(t *testing.T) {
+	box := NewCounterBox()
+	m := box.GetMaxWithTime("v")
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				m.Set(i*8 + g)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if v, at := m.ValueAt(); v != 7999 || at.IsZero() {
+		t.Errorf("want: 7999 with time, got %d %v", v, at)
+	}
+}
+
+func TestGetMaxWithTimeExisting(t *testing.T) {
+	box := NewCounterBox()
+	box.GetMax("plain")
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic for a max created without time")
+		}
+	}()
+	box.GetMaxWithTime("plain")
+}
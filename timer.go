@@ -0,0 +1,36 @@
+package counters
+
+import "time"
+
+// observeDuration records d in the box timer unit into counters name.count
+// and name.total and into min and max values of name.
+func (c *CounterBox) observeDuration(name string, d time.Duration) {
+	if c.timerUnit > 0 {
+		d /= c.timerUnit
+	}
+	c.GetCounter(name + ".count").Increment()
+	c.GetCounter(name + ".total").IncrementByDuration(d)
+	c.GetMax(name).SetDuration(d)
+	c.GetMin(name).SetDuration(d)
+}
+
+// Time runs fn and records how long it took. The number of calls is kept in
+// counter name.count, the sum of durations in name.total, the longest and
+// the shortest duration in max and min values of name.
+func (c *CounterBox) Time(name string, fn func()) {
+	start := c.clock.Now()
+	fn()
+	c.observeDuration(name, c.clock.Now().Sub(start))
+}
+
+// TimeErr works like Time, additionally it counts returned errors in
+// counter name.errors. The error returned by fn is passed through.
+func (c *CounterBox) TimeErr(name string, fn func() error) error {
+	start := c.clock.Now()
+	err := fn()
+	c.observeDuration(name, c.clock.Now().Sub(start))
+	if err != nil {
+		c.GetCounter(name + ".errors").Increment()
+	}
+	return err
+}
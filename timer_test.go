@@ -0,0 +1,75 @@
+package counters
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTime(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	box.Time("op", func() { clock.Advance(3 * time.Millisecond) })
+	box.Time("op", func() { clock.Advance(5 * time.Millisecond) })
+
+	if v := box.GetCounter("op.count").Value(); v != 2 {
+		t.Errorf("count, want: 2, got %d", v)
+	}
+	if v := box.GetCounter("op.total").Value(); v != int64(8*time.Millisecond) {
+		t.Errorf("total, want: %d, got %d", 8*time.Millisecond, v)
+	}
+	if v := box.GetMax("op").Value(); v != int64(5*time.Millisecond) {
+		t.Errorf("max, want: %d, got %d", 5*time.Millisecond, v)
+	}
+	if v := box.GetMin("op").Value(); v != int64(3*time.Millisecond) {
+		t.Errorf("min, want: %d, got %d", 3*time.Millisecond, v)
+	}
+}
+
+func TestTimeErr(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	errTest := errors.New("test")
+	if err := box.TimeErr("op", func() error { clock.Advance(time.Second); return errTest }); err != errTest {
+		t.Errorf("want error %v, got %v", errTest, err)
+	}
+	if err := box.TimeErr("op", func() error { return nil }); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+
+	if v := box.GetCounter("op.count").Value(); v != 2 {
+		t.Errorf("count, want: 2, got %d", v)
+	}
+	if v := box.GetCounter("op.errors").Value(); v != 1 {
+		t.Errorf("errors, want: 1, got %d", v)
+	}
+	if v := box.GetCounter("op.total").Value(); v != int64(time.Second) {
+		t.Errorf("total, want: %d, got %d", time.Second, v)
+	}
+}
+
+func TestTimeWithTimerUnit(t *testing.T) {
+	tests := []struct {
+		unit time.Duration
+		want int64
+	}{
+		{0, 2500000},
+		{time.Nanosecond, 2500000},
+		{time.Microsecond, 2500},
+		{time.Millisecond, 2},
+	}
+	for _, tt := range tests {
+		clock := newFakeClock()
+		box := NewCounterBox(WithClock(clock), WithTimerUnit(tt.unit))
+		box.Time("op", func() { clock.Advance(2500 * time.Microsecond) })
+		if v := box.GetCounter("op.total").Value(); v != tt.want {
+			t.Errorf("unit %v: total, want: %d, got %d", tt.unit, tt.want, v)
+		}
+		if v := box.GetMax("op").Value(); v != tt.want {
+			t.Errorf("unit %v: max, want: %d, got %d", tt.unit, tt.want, v)
+		}
+		if v := box.GetMin("op").Value(); v != tt.want {
+			t.Errorf("unit %v: min, want: %d, got %d", tt.unit, tt.want, v)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package counters
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// treeNode is a part of a counter name in a tree output.
+type treeNode struct {
+	children map[string]*treeNode
+	value    int64
+	hasValue bool
+}
+
+func newTree(pairs []CounterPair, sep string) *treeNode {
+	root := &treeNode{}
+	for _, p := range pairs {
+		n := root
+		for _, part := range strings.Split(p.Name, sep) {
+			if n.children == nil {
+				n.children = make(map[string]*treeNode)
+			}
+			child, ok := n.children[part]
+			if !ok {
+				child = &treeNode{}
+				n.children[part] = child
+			}
+			n = child
+		}
+		n.value, n.hasValue = p.Value, true
+	}
+	return root
+}
+
+func (n *treeNode) writeTo(w io.Writer, depth int) {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	indent := strings.Repeat("  ", depth)
+	for _, name := range names {
+		child := n.children[name]
+		if child.hasValue {
+			fmt.Fprintf(w, "\n%s%s: %d", indent, name, child.value)
+		} else {
+			fmt.Fprintf(w, "\n%s%s", indent, name)
+		}
+		child.writeTo(w, depth+1)
+	}
+}
+
+// WriteToTree works like WriteTo but splits names of counters on sep and
+// prints them as an indented tree, e.g. db.pool.active and db.pool.idle
+// are printed under db and pool.
+func (c *CounterBox) WriteToTree(w io.Writer, sep string) (int64, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("== Counters ==")
+	newTree(c.Pairs(), sep).writeTo(buf, 1)
+	buf.WriteString("\n== Min values ==")
+	newTree(c.MinPairs(), sep).writeTo(buf, 1)
+	buf.WriteString("\n== Max values ==")
+	newTree(c.MaxPairs(), sep).writeTo(buf, 1)
+	return buf.WriteTo(w)
+}
@@ -0,0 +1,34 @@
+package counters
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteToTree(t *testing.T) {
+	box := NewCounterBox()
+	box.GetCounter("db.pool.active").IncrementBy(5)
+	box.GetCounter("db.pool.idle").IncrementBy(2)
+	box.GetCounter("db").IncrementBy(1)
+	box.GetCounter("requests").IncrementBy(7)
+	box.GetMin("db.latency").Set(3)
+	box.GetMax("db.latency").Set(9)
+
+	buf := &strings.Builder{}
+	box.WriteToTree(buf, ".")
+	want := `== Counters ==
+  db: 1
+    pool
+      active: 5
+      idle: 2
+  requests: 7
+== Min values ==
+  db
+    latency: 3
+== Max values ==
+  db
+    latency: 9`
+	if got := buf.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
@@ -0,0 +1,51 @@
+package counters
+
+import (
+	"sync"
+	"time"
+)
+
+// uniqueSet keeps keys seen by CountUnique with the time they were counted.
+type uniqueSet struct {
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+// add reports whether key wasn't counted within window before now and
+// remembers it. Expired keys are removed at most once per window, so
+// the set keeps only keys of about the last two windows.
+func (s *uniqueSet) add(key string, now time.Time, window time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.lastSweep) >= window {
+		for k, t := range s.seen {
+			if now.Sub(t) >= window {
+				delete(s.seen, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	if t, ok := s.seen[key]; ok && now.Sub(t) < window {
+		return false
+	}
+	s.seen[key] = now
+	return true
+}
+
+// CountUnique increments counter name unless key was already counted
+// within the last window, e.g. to count unique users logging in per minute.
+// A key counts again when window passed since it was counted. It uses the
+// box clock and reports whether the key was counted.
+func (c *CounterBox) CountUnique(name, key string, window time.Duration) bool {
+	name = c.checkName(name)
+	value, ok := c.unique.Load(name)
+	if !ok {
+		value, _ = c.unique.LoadOrStore(name, &uniqueSet{seen: make(map[string]time.Time)})
+	}
+	if !value.(*uniqueSet).add(key, c.clock.Now(), window) {
+		return false
+	}
+	c.GetCounter(name).Increment()
+	return true
+}
@@ -0,0 +1,50 @@
+package counters
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCountUnique(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	for _, user := range []string{"alice", "bob", "alice", "alice", "bob"} {
+		box.CountUnique("logins", user, time.Minute)
+	}
+	if v := box.GetCounter("logins").Value(); v != 2 {
+		t.Errorf("want: 2, got %d", v)
+	}
+
+	clock.Advance(30 * time.Second)
+	if box.CountUnique("logins", "alice", time.Minute) {
+		t.Error("alice should not count again within the window")
+	}
+	if !box.CountUnique("logins", "carol", time.Minute) {
+		t.Error("carol should count")
+	}
+	clock.Advance(30 * time.Second)
+	if !box.CountUnique("logins", "alice", time.Minute) {
+		t.Error("alice should count again after the window")
+	}
+	if box.CountUnique("logins", "carol", time.Minute) {
+		t.Error("carol should not count again within the window")
+	}
+	if v := box.GetCounter("logins").Value(); v != 4 {
+		t.Errorf("want: 4, got %d", v)
+	}
+}
+
+func TestCountUniqueExpires(t *testing.T) {
+	clock := newFakeClock()
+	box := NewCounterBox(WithClock(clock))
+	for i := 0; i < 100; i++ {
+		box.CountUnique("users", strconv.Itoa(i), time.Minute)
+	}
+	clock.Advance(time.Minute)
+	box.CountUnique("users", "new", time.Minute)
+	value, _ := box.unique.Load("users")
+	if n := len(value.(*uniqueSet).seen); n != 1 {
+		t.Errorf("want expired keys removed, got %d keys", n)
+	}
+}